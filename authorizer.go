@@ -0,0 +1,235 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// ErrAccessDenied is returned by Authorizers to deny a request.
+var ErrAccessDenied = errors.New("access denied")
+
+// AuthorizationRequest describes the access a DID requests a token for.
+type AuthorizationRequest struct {
+	// DID is the authenticated DID.
+	DID string
+
+	// OnBehalfOf is the DID DID acts on behalf of, if the request carries a Delegation. Its permissions should apply,
+	// within the resources it delegated.
+	OnBehalfOf string
+
+	// Resource is the X-Resource header of the request.
+	Resource string
+
+	// Grants lists the resources and methods requested. Requests without grants are for Resource, with the methods of
+	// the ProtectedMappings serving it.
+	Grants []Grant
+}
+
+// principal returns the DID whose permissions apply to req: OnBehalfOf for delegated requests, DID otherwise.
+func (req AuthorizationRequest) principal() string {
+	if req.OnBehalfOf != "" {
+		return req.OnBehalfOf
+	}
+
+	return req.DID
+}
+
+// Authorization is the access an Authorizer allows.
+type Authorization struct {
+	// Grants lists the resources and methods the token gives access to, among the requested ones: other resources
+	// and methods are dropped.
+	Grants []Grant
+
+	// Claims holds additional claims written in the token, the ones didcomauth sets such as did, on_behalf_of, csrf and
+	// the registered JWT claims are dropped, even when the token omits them.
+	Claims map[string]interface{}
+}
+
+// Authorizer decides what an authenticated DID can access, after it answered its challenge and before a token is
+// released to it. Refresh requests are authorized again.
+type Authorizer interface {
+	// Authorize returns the access allowed for req. Returning ErrAccessDenied, or no grants, denies the request with
+	// 403, other errors fail it with 500.
+	Authorize(ctx context.Context, req AuthorizationRequest) (Authorization, error)
+}
+
+// AuthorizerFunc is an adapter to use ordinary functions as Authorizer.
+type AuthorizerFunc func(ctx context.Context, req AuthorizationRequest) (Authorization, error)
+
+// Authorize implements the Authorizer interface.
+func (f AuthorizerFunc) Authorize(ctx context.Context, req AuthorizationRequest) (Authorization, error) {
+	return f(ctx, req)
+}
+
+// resourceMethods returns the methods of the ProtectedMappings serving resource, or every method grants can list if
+// none does.
+func (r *router) resourceMethods(resource string) []string {
+	var methods []string
+	for _, m := range validGrantMethods {
+		if _, ok := r.mappingFor(resource, m); ok {
+			methods = append(methods, m)
+		}
+	}
+
+	if len(methods) == 0 {
+		return validGrantMethods
+	}
+
+	return methods
+}
+
+// narrowGrants returns the grants among allowed which are within requested: the resources requested, with the methods
+// requested for them.
+func narrowGrants(requested, allowed []Grant) []Grant {
+	methods := make(map[string][]string, len(requested))
+	for _, g := range requested {
+		methods[g.Resource] = append(methods[g.Resource], g.Methods...)
+	}
+
+	granted := make(map[string][]string, len(allowed))
+	var grants []Grant
+	for _, g := range allowed {
+		var ms []string
+		for _, m := range g.Methods {
+			if containsString(methods[g.Resource], m) && !containsString(granted[g.Resource], m) {
+				ms = append(ms, m)
+				granted[g.Resource] = append(granted[g.Resource], m)
+			}
+		}
+
+		if len(ms) > 0 {
+			grants = append(grants, Grant{Resource: g.Resource, Methods: ms})
+		}
+	}
+
+	return grants
+}
+
+// authorizerChain is an Authorizer calling its Authorizers in turn, each one with the grants allowed by the previous
+// ones.
+type authorizerChain []Authorizer
+
+// ChainAuthorizers returns an Authorizer which calls authorizers in turn, each one with the grants allowed by the
+// previous ones, and merges the claims they add. The request is denied as soon as one of them denies it.
+func ChainAuthorizers(authorizers ...Authorizer) Authorizer {
+	return authorizerChain(authorizers)
+}
+
+// Authorize implements the Authorizer interface.
+func (c authorizerChain) Authorize(ctx context.Context, req AuthorizationRequest) (Authorization, error) {
+	var claims map[string]interface{}
+	for _, authorizer := range c {
+		a, err := authorizer.Authorize(ctx, req)
+		if err != nil {
+			return Authorization{}, err
+		}
+
+		// authorizers can only narrow the requested grants
+		grants := narrowGrants(req.Grants, a.Grants)
+		if len(grants) == 0 {
+			return Authorization{}, ErrAccessDenied
+		}
+
+		req.Grants = grants
+		for k, v := range a.Claims {
+			if claims == nil {
+				claims = make(map[string]interface{})
+			}
+
+			claims[k] = v
+		}
+	}
+
+	return Authorization{Grants: req.Grants, Claims: claims}, nil
+}
+
+// authorize returns claims narrowed by Config.Policy and then by Config.Authorizer, if any, and by the membership
+// required by the ProtectedMappings of the granted resources.
+// Authorized claims always carry grants, those of requests without grants are made explicit.
+func (r *router) authorize(ctx context.Context, claims DidComAuthClaims) (DidComAuthClaims, error) {
+	var chain authorizerChain
+	if p := r.policy(); p != nil {
+		chain = append(chain, p)
+	}
+
+	if r.config.Authorizer != nil {
+		chain = append(chain, r.config.Authorizer)
+	}
+
+	if len(chain) == 0 {
+		return claims, nil
+	}
+
+	grants := claims.Grants
+	if len(grants) == 0 {
+		grants = []Grant{{Resource: claims.Resource, Methods: r.resourceMethods(claims.Resource)}}
+	}
+
+	a, err := chain.Authorize(ctx, AuthorizationRequest{
+		DID:        claims.DID,
+		OnBehalfOf: claims.OnBehalfOf,
+		Resource:   claims.Resource,
+		Grants:     grants,
+	})
+	if err != nil {
+		return DidComAuthClaims{}, err
+	}
+
+	if membership, ok := a.Claims[membershipClaim].(string); ok {
+		claims.Membership = membership
+		delete(a.Claims, membershipClaim)
+	}
+
+	claims.Grants = r.membershipGrants(claims.Membership, a.Grants)
+	if len(claims.Grants) == 0 {
+		return DidComAuthClaims{}, ErrAccessDenied
+	}
+
+	if len(a.Claims) > 0 {
+		claims.Extra = a.Claims
+	}
+
+	return claims, nil
+}
+
+// writeAuthorizationError writes the response for an authorization failure err.
+func writeAuthorizationError(rw http.ResponseWriter, err error) {
+	if errors.Is(err, ErrAccessDenied) {
+		writeError(rw, http.StatusForbidden, ErrAccessDenied)
+		return
+	}
+
+	log.Println(err)
+	writeError(rw, http.StatusInternalServerError, errors.New("could not authorize request"))
+}
+
+// reservedClaims are the claims didcomauth sets, which Extra can't set even when they're omitted.
+var reservedClaims = []string{
+	"iss", "sub", "aud", "exp", "nbf", "iat", "jti",
+	"resource", "did", "on_behalf_of", "grants", membershipClaim, "csrf",
+}
+
+// MarshalJSON implements the json.Marshaler interface, adding Extra to the claims but the reserved ones.
+func (c DidComAuthClaims) MarshalJSON() ([]byte, error) {
+	type claims DidComAuthClaims
+	b, err := json.Marshal(claims(c))
+	if err != nil || len(c.Extra) == 0 {
+		return b, err
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	for k, v := range c.Extra {
+		if !containsString(reservedClaims, k) {
+			m[k] = v
+		}
+	}
+
+	return json.Marshal(m)
+}
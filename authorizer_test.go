@@ -0,0 +1,227 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_router_resourceMethods(t *testing.T) {
+	r := &router{config: Config{
+		ProtectedBasePath: "/protected",
+		ProtectedPaths: []ProtectedMapping{
+			{Methods: []string{http.MethodGet}, Path: "/upload/{id}"},
+			{Methods: []string{http.MethodPut, http.MethodDelete}, Path: "/upload/{id}"},
+		},
+	}}
+
+	require.Equal(t, []string{http.MethodGet, http.MethodPut, http.MethodDelete}, r.resourceMethods("/protected/upload/12"))
+	require.Equal(t, validGrantMethods, r.resourceMethods("/protected/unknown"))
+}
+
+func Test_router_authorize(t *testing.T) {
+	ctx := context.Background()
+	claims := DidComAuthClaims{DID: "did", Resource: "/protected/upload/12"}
+	r := &router{config: Config{
+		ProtectedBasePath: "/protected",
+		ProtectedPaths:    []ProtectedMapping{{Methods: []string{http.MethodGet, http.MethodPut}, Path: "/upload/{id}"}},
+	}}
+
+	// without authorizer claims are left as they are
+	got, err := r.authorize(ctx, claims)
+	require.NoError(t, err)
+	require.Equal(t, claims, got)
+
+	var received AuthorizationRequest
+	r.config.Authorizer = AuthorizerFunc(func(ctx context.Context, req AuthorizationRequest) (Authorization, error) {
+		received = req
+		return Authorization{
+			Grants: []Grant{{Resource: req.Resource, Methods: []string{http.MethodGet}}},
+			Claims: map[string]interface{}{"tier": "gold"},
+		}, nil
+	})
+
+	got, err = r.authorize(ctx, claims)
+	require.NoError(t, err)
+	require.Equal(t, AuthorizationRequest{
+		DID:      "did",
+		Resource: "/protected/upload/12",
+		Grants:   []Grant{{Resource: "/protected/upload/12", Methods: []string{http.MethodGet, http.MethodPut}}},
+	}, received, "requests without grants are made explicit")
+	require.Equal(t, []Grant{{Resource: "/protected/upload/12", Methods: []string{http.MethodGet}}}, got.Grants)
+	require.Equal(t, "gold", got.Extra["tier"])
+
+	tests := []struct {
+		name       string
+		authorizer AuthorizerFunc
+		wantErr    error
+	}{
+		{"denied", func(context.Context, AuthorizationRequest) (Authorization, error) {
+			return Authorization{}, ErrAccessDenied
+		}, ErrAccessDenied},
+		{"no grants", func(context.Context, AuthorizationRequest) (Authorization, error) {
+			return Authorization{}, nil
+		}, ErrAccessDenied},
+		{"failure", func(context.Context, AuthorizationRequest) (Authorization, error) {
+			return Authorization{}, errors.New("failure")
+		}, errors.New("failure")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r.config.Authorizer = tt.authorizer
+			_, err := r.authorize(ctx, claims)
+			require.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func Test_narrowGrants(t *testing.T) {
+	get, put := http.MethodGet, http.MethodPut
+	requested := []Grant{
+		{Resource: "/protected/profile", Methods: []string{get}},
+		{Resource: "/protected/upload/*", Methods: []string{get, put}},
+	}
+
+	tests := []struct {
+		name    string
+		allowed []Grant
+		want    []Grant
+	}{
+		{"same", requested, requested},
+		{"narrower", []Grant{{Resource: "/protected/upload/*", Methods: []string{put}}}, []Grant{{Resource: "/protected/upload/*", Methods: []string{put}}}},
+		{"other resource", []Grant{{Resource: "/protected/*", Methods: []string{get}}}, nil},
+		{"other method", []Grant{{Resource: "/protected/profile", Methods: []string{get, put, http.MethodDelete}}}, []Grant{{Resource: "/protected/profile", Methods: []string{get}}}},
+		{"repeated", []Grant{{Resource: "/protected/profile", Methods: []string{get}}, {Resource: "/protected/profile", Methods: []string{get}}}, []Grant{{Resource: "/protected/profile", Methods: []string{get}}}},
+		{"none", nil, nil},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, narrowGrants(requested, tt.allowed))
+		})
+	}
+}
+
+func Test_router_authorize_widening(t *testing.T) {
+	r := &router{config: Config{
+		Authorizer: AuthorizerFunc(func(_ context.Context, req AuthorizationRequest) (Authorization, error) {
+			return Authorization{Grants: append(req.Grants,
+				Grant{Resource: "/protected/admin", Methods: []string{http.MethodGet}},
+				Grant{Resource: req.Resource, Methods: []string{http.MethodDelete}},
+			)}, nil
+		}),
+	}}
+
+	claims := DidComAuthClaims{
+		DID:      "did",
+		Resource: "/protected/profile",
+		Grants:   []Grant{{Resource: "/protected/profile", Methods: []string{http.MethodGet}}},
+	}
+
+	got, err := r.authorize(context.Background(), claims)
+	require.NoError(t, err)
+	require.Equal(t, claims.Grants, got.Grants, "authorizers can't widen the requested grants")
+
+	r.config.Authorizer = AuthorizerFunc(func(context.Context, AuthorizationRequest) (Authorization, error) {
+		return Authorization{Grants: []Grant{{Resource: "/protected/admin", Methods: []string{http.MethodGet}}}}, nil
+	})
+
+	_, err = r.authorize(context.Background(), claims)
+	require.Equal(t, ErrAccessDenied, err)
+}
+
+func TestChainAuthorizers(t *testing.T) {
+	get := Grant{Resource: "/get", Methods: []string{http.MethodGet}}
+	put := Grant{Resource: "/put", Methods: []string{http.MethodPut}}
+
+	var second AuthorizationRequest
+	a := ChainAuthorizers(
+		AuthorizerFunc(func(_ context.Context, req AuthorizationRequest) (Authorization, error) {
+			return Authorization{Grants: req.Grants[:1], Claims: map[string]interface{}{"first": 1, "tier": "silver"}}, nil
+		}),
+		AuthorizerFunc(func(_ context.Context, req AuthorizationRequest) (Authorization, error) {
+			second = req
+			return Authorization{Grants: req.Grants, Claims: map[string]interface{}{"tier": "gold"}}, nil
+		}),
+	)
+
+	got, err := a.Authorize(context.Background(), AuthorizationRequest{DID: "did", Resource: "/get", Grants: []Grant{get, put}})
+	require.NoError(t, err)
+	require.Equal(t, []Grant{get}, second.Grants, "authorizers get the grants allowed by the previous ones")
+	require.Equal(t, Authorization{Grants: []Grant{get}, Claims: map[string]interface{}{"first": 1, "tier": "gold"}}, got)
+
+	denied := ChainAuthorizers(
+		AuthorizerFunc(func(context.Context, AuthorizationRequest) (Authorization, error) {
+			return Authorization{}, nil
+		}),
+		AuthorizerFunc(func(context.Context, AuthorizationRequest) (Authorization, error) {
+			t.Fatal("authorizers after a denial aren't called")
+			return Authorization{}, nil
+		}),
+	)
+
+	_, err = denied.Authorize(context.Background(), AuthorizationRequest{DID: "did", Resource: "/get", Grants: []Grant{get}})
+	require.Equal(t, ErrAccessDenied, err)
+}
+
+func TestDidComAuthClaims_MarshalJSON(t *testing.T) {
+	c := DidComAuthClaims{
+		Resource: "/path",
+		DID:      "did",
+		Extra: map[string]interface{}{
+			"tier":         "gold",
+			"did":          "another did",
+			"on_behalf_of": "another did",
+			"csrf":         "token",
+			"aud":          "another audience",
+		},
+	}
+
+	b, err := json.Marshal(&c)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"resource":"/path","did":"did","tier":"gold"}`, string(b), "extra claims can't set ours, even omitted")
+
+	c.Extra = nil
+	b, err = json.Marshal(c)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"resource":"/path","did":"did"}`, string(b))
+}
+
+func Test_router_refreshPOSTHandler_authorizer(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	allowed := true
+	r := &router{
+		config: Config{
+			JWTSecret:            "secret",
+			RefreshTokenLifetime: time.Hour,
+			Authorizer: AuthorizerFunc(func(ctx context.Context, req AuthorizationRequest) (Authorization, error) {
+				if !allowed {
+					return Authorization{}, ErrAccessDenied
+				}
+
+				return Authorization{Grants: req.Grants, Claims: map[string]interface{}{"tier": "gold"}}, nil
+			}),
+		},
+		cp: newCTest(false),
+	}
+
+	claims, err := r.authorize(context.Background(), DidComAuthClaims{DID: did, Resource: "/path"})
+	require.NoError(t, err)
+	resp, err := r.releaseTokens(context.Background(), claims, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, "gold", rawClaims(resp.Token)["tier"])
+
+	// refresh requests are authorized again
+	allowed = false
+	body, err := json.Marshal(RefreshRequest{RefreshToken: resp.RefreshToken})
+	require.NoError(t, err)
+	rr := refreshRequest(t, r, did, "/path", string(body))
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Contains(t, rr.Body.String(), "access denied")
+}
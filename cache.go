@@ -1,8 +1,64 @@
 package didcomauth
 
-// cache represents an object capable of setting and getting data from a backing storage (redis, a map...).
-type cache interface {
-	Set(c Challenge) error
-	Get(did string) (Challenge, error)
-	Delete(did string)
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrEntryNotFound is returned by ChallengeStore implementations when there is no value for a key.
+var ErrEntryNotFound = errors.New("entry not found")
+
+// ChallengeStore is the storage backing didcomauth: it holds the challenges issued to clients until they answer
+// them, along with the other short-lived data didcomauth keeps, like cached DDOs.
+//
+// Values are opaque bytes stored under string keys, and must stop being returned once their expiry elapses.
+// Implementations must be safe for concurrent use.
+type ChallengeStore interface {
+	// Set stores value under key for expiry, replacing any value already stored under key.
+	Set(ctx context.Context, key string, value []byte, expiry time.Duration) error
+
+	// Get returns the value stored under key, or ErrEntryNotFound if there's none or it expired.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete removes the value stored under key, returning ErrEntryNotFound if there was none.
+	// When several callers delete the same key concurrently, only one of them must succeed.
+	Delete(ctx context.Context, key string) error
+}
+
+const (
+	keyFmt              = "challenge-%s"
+	challengeExpiryTime = 30 * time.Second // time in which we assume a Challenge is valid
+)
+
+func getKey(did string) string {
+	return fmt.Sprintf(keyFmt, did)
+}
+
+// setChallenge stores c in s as the challenge issued to c.DID.
+func setChallenge(ctx context.Context, s ChallengeStore, c Challenge) error {
+	b, err := c.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return s.Set(ctx, getKey(c.DID), b, challengeExpiryTime)
+}
+
+// getChallenge returns the challenge issued to did stored in s.
+func getChallenge(ctx context.Context, s ChallengeStore, did string) (Challenge, error) {
+	b, err := s.Get(ctx, getKey(did))
+	if err != nil {
+		return Challenge{}, err
+	}
+
+	var c Challenge
+	return c, json.Unmarshal(b, &c)
+}
+
+// deleteChallenge removes the challenge issued to did from s.
+func deleteChallenge(ctx context.Context, s ChallengeStore, did string) error {
+	return s.Delete(ctx, getKey(did))
 }
@@ -1,25 +1,234 @@
 package didcomauth
 
-type mem struct {
-	store map[string]Challenge
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const (
+	memShards               = 32
+	memMinShardEntries      = 1024 // smaller stores have fewer shards, so that their entries are spread evenly
+	defaultMemMaxEntries    = 100000
+	defaultMemSweepInterval = time.Minute
+
+	// memEvictionSamples is the number of entries looked at to pick the one to evict from a full shard.
+	memEvictionSamples = 8
+)
+
+// MemoryOptions configures the in-memory store used when Config.CacheType is CacheTypeMemory.
+type MemoryOptions struct {
+	// MaxEntries is the maximum number of entries held, 100000 if zero.
+	// Entries are split among up to 32 shards, each holding an equal share of MaxEntries: when the shard of a new
+	// entry is full, storing it evicts one of the entries of the shard closest to expiry, even if other shards have
+	// room. Stores of less than 2048 entries have a single shard, hence they only evict when MaxEntries are held.
+	MaxEntries int
+
+	// SweepInterval is the interval at which expired entries are removed in background, 1 minute if zero.
+	// Negative values disable background sweeping, expired entries are then only removed when accessed or evicted.
+	SweepInterval time.Duration
+}
+
+// memShard is a lock-protected portion of a MemoryStore.
+type memShard struct {
+	mu      sync.Mutex
+	entries map[string]memEntry
+}
+
+// memEntry is a value stored in a MemoryStore.
+type memEntry struct {
+	value  []byte
+	expiry time.Time
+}
+
+// MemoryStore is a sharded in-memory ChallengeStore, whose entries expire like redis ones do.
+type MemoryStore struct {
+	shards        []*memShard
+	maxPerShard   int
+	sweepInterval time.Duration
+
+	done chan struct{}
+	once sync.Once
+}
+
+// NewMemoryStore returns a MemoryStore configured by opts, and starts its background sweeper which runs until Close
+// is called. Config.CacheType CacheTypeMemory uses one which is never closed, set Config.ChallengeStore to one
+// instead to control its lifetime.
+func NewMemoryStore(opts MemoryOptions) *MemoryStore {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = defaultMemMaxEntries
+	}
+
+	if opts.SweepInterval == 0 {
+		opts.SweepInterval = defaultMemSweepInterval
+	}
+
+	shards := opts.MaxEntries / memMinShardEntries
+	switch {
+	case shards < 1:
+		shards = 1
+	case shards > memShards:
+		shards = memShards
+	}
+
+	// rounding down, so that shards never hold more than MaxEntries together
+	m := &MemoryStore{
+		shards:        make([]*memShard, shards),
+		maxPerShard:   opts.MaxEntries / shards,
+		sweepInterval: opts.SweepInterval,
+		done:          make(chan struct{}),
+	}
+
+	for i := range m.shards {
+		m.shards[i] = &memShard{entries: make(map[string]memEntry)}
+	}
+
+	if m.sweepInterval > 0 {
+		go m.sweepLoop()
+	}
+
+	return m
+}
+
+// Close stops the background sweeper of m, which keeps working as a ChallengeStore.
+func (m *MemoryStore) Close() {
+	m.once.Do(func() {
+		close(m.done)
+	})
+}
+
+// Set implements the ChallengeStore interface.
+func (m *MemoryStore) Set(_ context.Context, key string, value []byte, expiry time.Duration) error {
+	now := time.Now()
+	s := m.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= m.maxPerShard {
+		s.evict(now)
+	}
+
+	s.entries[key] = memEntry{
+		value:  value,
+		expiry: now.Add(expiry),
+	}
+
+	return nil
 }
 
-// newMem returns a new instance of mem with an in-memory map as backing store, typically used for testing.
-func newMem() cache {
-	return cache(mem{store: make(map[string]Challenge)})
+// Get implements the ChallengeStore interface.
+func (m *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s := m.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, ErrEntryNotFound
+	}
+
+	if time.Now().After(e.expiry) {
+		delete(s.entries, key)
+		return nil, ErrEntryNotFound
+	}
+
+	return e.value, nil
 }
 
-// Set implements the cache interface for redis.
-func (m mem) Set(c Challenge) error {
-	m.store[getKey(c.DID)] = c
+// Delete implements the ChallengeStore interface.
+func (m *MemoryStore) Delete(_ context.Context, key string) error {
+	s := m.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return ErrEntryNotFound
+	}
+
+	delete(s.entries, key)
+	if time.Now().After(e.expiry) {
+		return ErrEntryNotFound
+	}
+
 	return nil
 }
 
-// Get implements the cache interface for redis.
-func (m mem) Get(did string) (Challenge, error) {
-	return m.store[getKey(did)], nil
+// Len returns the number of entries held by m, including expired ones not swept yet.
+func (m *MemoryStore) Len() int {
+	n := 0
+	for _, s := range m.shards {
+		s.mu.Lock()
+		n += len(s.entries)
+		s.mu.Unlock()
+	}
+
+	return n
+}
+
+// shard returns the shard key belongs to.
+func (m *MemoryStore) shard(key string) *memShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return m.shards[h.Sum32()%uint32(len(m.shards))]
+}
+
+// sweepLoop removes expired entries every sweepInterval, until m is closed.
+func (m *MemoryStore) sweepLoop() {
+	t := time.NewTicker(m.sweepInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-t.C:
+			m.sweep(now)
+		}
+	}
 }
 
-func (m mem) Delete(did string) {
-	delete(m.store, did)
+// sweep removes the entries expired at now, locking one shard at a time.
+func (m *MemoryStore) sweep(now time.Time) {
+	for _, s := range m.shards {
+		s.mu.Lock()
+		for k, e := range s.entries {
+			if now.After(e.expiry) {
+				delete(s.entries, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// evict makes room for a new entry in s, which must be locked.
+// An expired entry is evicted if one is found among a few sampled ones, otherwise the sampled entry closest to
+// expiry is.
+func (s *memShard) evict(now time.Time) {
+	var (
+		victim  string
+		soonest time.Time
+		sampled int
+	)
+
+	for k, e := range s.entries {
+		if now.After(e.expiry) {
+			victim = k
+			break
+		}
+
+		if sampled == 0 || e.expiry.Before(soonest) {
+			victim, soonest = k, e.expiry
+		}
+
+		if sampled++; sampled == memEvictionSamples {
+			break
+		}
+	}
+
+	delete(s.entries, victim)
 }
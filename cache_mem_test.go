@@ -0,0 +1,168 @@
+package didcomauth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mem_Bytes(t *testing.T) {
+	m := NewMemoryStore(MemoryOptions{})
+	defer m.Close()
+	ctx := context.Background()
+
+	_, err := m.Get(ctx, "key")
+	require.Equal(t, ErrEntryNotFound, err)
+
+	require.NoError(t, m.Set(ctx, "key", []byte("value"), time.Minute))
+	v, err := m.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), v)
+
+	require.NoError(t, m.Set(ctx, "expiring", []byte("value"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, err = m.Get(ctx, "expiring")
+	require.Equal(t, ErrEntryNotFound, err)
+}
+
+func Test_mem_Challenge(t *testing.T) {
+	m := NewMemoryStore(MemoryOptions{})
+	defer m.Close()
+	ctx := context.Background()
+
+	c := Challenge{Challenge: "challenge", Timestamp: 1, DID: "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"}
+
+	_, err := getChallenge(ctx, m, c.DID)
+	require.Equal(t, ErrEntryNotFound, err)
+
+	require.NoError(t, setChallenge(ctx, m, c))
+	got, err := getChallenge(ctx, m, c.DID)
+	require.NoError(t, err)
+	require.Equal(t, c, got)
+
+	// only the first delete succeeds
+	require.NoError(t, deleteChallenge(ctx, m, c.DID))
+	require.Equal(t, ErrEntryNotFound, deleteChallenge(ctx, m, c.DID))
+	_, err = getChallenge(ctx, m, c.DID)
+	require.Equal(t, ErrEntryNotFound, err)
+	require.Equal(t, 0, m.Len())
+}
+
+func Test_mem_MaxEntries(t *testing.T) {
+	m := NewMemoryStore(MemoryOptions{MaxEntries: memShards * 2})
+	defer m.Close()
+	ctx := context.Background()
+
+	for i := 0; i < memShards*20; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		require.NoError(t, m.Set(ctx, key, []byte("value"), time.Minute))
+
+		// the entry just stored is never the one evicted
+		_, err := m.Get(ctx, key)
+		require.NoError(t, err)
+		require.LessOrEqual(t, m.Len(), memShards*2)
+	}
+
+	// overwriting an existing entry doesn't evict anything
+	m = NewMemoryStore(MemoryOptions{MaxEntries: 1})
+	defer m.Close()
+
+	require.NoError(t, m.Set(ctx, "key", []byte("value"), time.Minute))
+	require.NoError(t, m.Set(ctx, "key", []byte("other value"), time.Minute))
+	v, err := m.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("other value"), v)
+}
+
+func Test_mem_MaxEntries_bound(t *testing.T) {
+	ctx := context.Background()
+
+	for _, max := range []int{1, 10, 64, 100, 2047, 5000, 100001} {
+		max := max
+		t.Run(fmt.Sprint(max), func(t *testing.T) {
+			m := NewMemoryStore(MemoryOptions{MaxEntries: max})
+			defer m.Close()
+			require.LessOrEqual(t, len(m.shards)*m.maxPerShard, max)
+			require.LessOrEqual(t, len(m.shards), memShards)
+
+			for i := 0; i < 2*max; i++ {
+				require.NoError(t, m.Set(ctx, fmt.Sprintf("key-%d", i), []byte("value"), time.Minute))
+			}
+
+			require.LessOrEqual(t, m.Len(), max)
+			if len(m.shards) == 1 {
+				require.Equal(t, max, m.Len(), "single shard stores fill up before evicting")
+			}
+		})
+	}
+}
+
+func Test_mem_evictsExpiredFirst(t *testing.T) {
+	s := &memShard{entries: make(map[string]memEntry)}
+	now := time.Now()
+
+	s.entries["fresh"] = memEntry{expiry: now.Add(time.Hour)}
+	s.entries["expired"] = memEntry{expiry: now.Add(-time.Second)}
+	s.entries["expiring"] = memEntry{expiry: now.Add(time.Minute)}
+
+	s.evict(now)
+	require.Len(t, s.entries, 2)
+	require.NotContains(t, s.entries, "expired")
+
+	s.evict(now)
+	require.Len(t, s.entries, 1)
+	require.Contains(t, s.entries, "fresh")
+}
+
+func Test_mem_sweep(t *testing.T) {
+	m := NewMemoryStore(MemoryOptions{SweepInterval: 5 * time.Millisecond})
+	defer m.Close()
+	ctx := context.Background()
+
+	require.NoError(t, m.Set(ctx, "expiring", []byte("value"), time.Millisecond))
+	require.NoError(t, m.Set(ctx, "key", []byte("value"), time.Minute))
+
+	// swept without being accessed
+	require.Eventually(t, func() bool { return m.Len() == 1 }, time.Second, 5*time.Millisecond)
+
+	_, err := m.Get(ctx, "key")
+	require.NoError(t, err)
+
+	// closed stores aren't swept anymore, but keep working
+	m.Close()
+	m.Close()
+	require.NoError(t, m.Set(ctx, "expiring", []byte("value"), time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, 2, m.Len())
+	_, err = m.Get(ctx, "expiring")
+	require.Equal(t, ErrEntryNotFound, err)
+}
+
+func Test_mem_concurrentAccess(t *testing.T) {
+	m := NewMemoryStore(MemoryOptions{MaxEntries: 64, SweepInterval: time.Millisecond})
+	defer m.Close()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				did := fmt.Sprintf("did-%d-%d", i, j%16)
+				_ = setChallenge(ctx, m, Challenge{Challenge: "c", Timestamp: 1, DID: did})
+				_, _ = getChallenge(ctx, m, did)
+				if j%3 == 0 {
+					_ = deleteChallenge(ctx, m, did)
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	require.LessOrEqual(t, m.Len(), 64)
+}
@@ -1,51 +1,186 @@
 package didcomauth
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"time"
 
 	redisClient "github.com/go-redis/redis"
 )
 
-const (
-	keyFmt              = "challenge-%s"
-	challengeExpiryTime = 30 * time.Second // time in which we assume a Challenge is valid
-)
+// redisPingTimeout bounds the startup ping of the redis server.
+const redisPingTimeout = 5 * time.Second
+
+// RedisOptions configures the redis store used when Config.CacheType is CacheTypeRedis.
+//
+// A single server is used by default, a Sentinel-monitored one if MasterName is set, and a Cluster if Cluster is
+// true.
+type RedisOptions struct {
+	// Client is a ready-made redis client, if set every other option but KeyPrefix is ignored.
+	Client redisClient.UniversalClient
+
+	// Addrs holds the address of the server, of the Sentinel nodes or of the Cluster seed nodes,
+	// Config.RedisHost if empty.
+	Addrs []string
+
+	// MasterName is the name of the master monitored by the Sentinel nodes in Addrs.
+	MasterName string
+
+	// Cluster tells that the nodes in Addrs are part of a Cluster.
+	Cluster bool
+
+	// Password authenticates the connections, DB selects the database. Clusters only have database 0.
+	Password string
+	DB       int
+
+	// TLSConfig enables TLS if not nil.
+	TLSConfig *tls.Config
+
+	// KeyPrefix is prepended to every key didcomauth writes, to share a database with other applications.
+	KeyPrefix string
+
+	// PoolSize is the maximum number of connections per node and MinIdleConns the number of idle connections kept
+	// open, go-redis defaults if zero.
+	PoolSize     int
+	MinIdleConns int
 
+	// DialTimeout, ReadTimeout and WriteTimeout bound network operations, go-redis defaults if zero.
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// validate checks that o describes a single redis deployment mode.
+func (o RedisOptions) validate() error {
+	if o.Client != nil {
+		return nil
+	}
+
+	if o.MasterName != "" && o.Cluster {
+		return errors.New("redis options can't set both a Sentinel master name and Cluster mode")
+	}
+
+	if o.Cluster && o.DB != 0 {
+		return errors.New("redis Cluster only supports database 0")
+	}
+
+	return nil
+}
+
+// client returns the redis client o describes, connecting to defaultAddr if o doesn't list any address.
+func (o RedisOptions) client(defaultAddr string) redisClient.UniversalClient {
+	if o.Client != nil {
+		return o.Client
+	}
+
+	addrs := o.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{defaultAddr}
+	}
+
+	switch {
+	case o.MasterName != "":
+		return redisClient.NewFailoverClient(&redisClient.FailoverOptions{
+			MasterName:    o.MasterName,
+			SentinelAddrs: addrs,
+			Password:      o.Password,
+			DB:            o.DB,
+			DialTimeout:   o.DialTimeout,
+			ReadTimeout:   o.ReadTimeout,
+			WriteTimeout:  o.WriteTimeout,
+			PoolSize:      o.PoolSize,
+			MinIdleConns:  o.MinIdleConns,
+			TLSConfig:     o.TLSConfig,
+		})
+	case o.Cluster:
+		return redisClient.NewClusterClient(&redisClient.ClusterOptions{
+			Addrs:        addrs,
+			Password:     o.Password,
+			DialTimeout:  o.DialTimeout,
+			ReadTimeout:  o.ReadTimeout,
+			WriteTimeout: o.WriteTimeout,
+			PoolSize:     o.PoolSize,
+			MinIdleConns: o.MinIdleConns,
+			TLSConfig:    o.TLSConfig,
+		})
+	default:
+		return redisClient.NewClient(&redisClient.Options{
+			Addr:         addrs[0],
+			Password:     o.Password,
+			DB:           o.DB,
+			DialTimeout:  o.DialTimeout,
+			ReadTimeout:  o.ReadTimeout,
+			WriteTimeout: o.WriteTimeout,
+			PoolSize:     o.PoolSize,
+			MinIdleConns: o.MinIdleConns,
+			TLSConfig:    o.TLSConfig,
+		})
+	}
+}
+
+// redis is a ChallengeStore backed by a redis deployment.
 type redis struct {
-	rc *redisClient.Client
+	rc     redisClient.UniversalClient
+	prefix string
 }
 
-// newRedis returns a new instance of redis with ru as redis host address.
-func newRedis(ru string) cache {
-	rc := redisClient.NewClient(&redisClient.Options{
-		Addr: ru,
-	})
+// newRedis returns a new instance of redis configured by opts, with defaultAddr as redis host address if opts
+// doesn't list any.
+func newRedis(opts RedisOptions, defaultAddr string) *redis {
+	return &redis{
+		rc:     opts.client(defaultAddr),
+		prefix: opts.KeyPrefix,
+	}
+}
 
-	return cache(redis{rc})
+// withContext returns the client of r bound to ctx, for the client types supporting it.
+func (r *redis) withContext(ctx context.Context) redisClient.Cmdable {
+	switch c := r.rc.(type) {
+	case *redisClient.Client:
+		return c.WithContext(ctx)
+	case *redisClient.ClusterClient:
+		return c.WithContext(ctx)
+	default:
+		return r.rc
+	}
 }
 
-func getKey(did string) string {
-	return fmt.Sprintf(keyFmt, did)
+// Set implements the ChallengeStore interface.
+func (r *redis) Set(ctx context.Context, key string, value []byte, expiry time.Duration) error {
+	return r.withContext(ctx).Set(r.prefix+key, value, expiry).Err()
 }
 
-// Set implements the cache interface for redis.
-func (r redis) Set(c Challenge) error {
-	return r.rc.Set(getKey(c.DID), c, challengeExpiryTime).Err()
+// Get implements the ChallengeStore interface.
+func (r *redis) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := r.withContext(ctx).Get(r.prefix + key).Bytes()
+	if err == redisClient.Nil {
+		return nil, ErrEntryNotFound
+	}
+
+	return b, err
 }
 
-// Get implements the cache interface for redis.
-func (r redis) Get(did string) (Challenge, error) {
-	b, err := r.rc.Get(getKey(did)).Bytes()
+// Delete implements the ChallengeStore interface.
+func (r *redis) Delete(ctx context.Context, key string) error {
+	n, err := r.withContext(ctx).Del(r.prefix + key).Result()
 	if err != nil {
-		return Challenge{}, err
+		return err
 	}
 
-	var c Challenge
-	return c, json.Unmarshal(b, &c)
+	if n == 0 {
+		return ErrEntryNotFound
+	}
+
+	return nil
 }
 
-func (r redis) Delete(did string) {
-	_ = r.rc.Del(did).Err()
+// Ping checks that the redis deployment is reachable.
+func (r *redis) Ping(ctx context.Context) error {
+	if err := r.withContext(ctx).Ping().Err(); err != nil {
+		return fmt.Errorf("could not reach redis, %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,244 @@
+package didcomauth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	redisClient "github.com/go-redis/redis"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is a minimal redis server speaking just enough RESP for the redis store: PING, AUTH, SELECT, SET, GET
+// and DEL, without expiry.
+type fakeRedis struct {
+	l        net.Listener
+	password string
+
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	f := &fakeRedis{l: l, password: password, keys: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go f.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { _ = l.Close() })
+	return f
+}
+
+func (f *fakeRedis) addr() string {
+	return f.l.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	for {
+		cmd, err := readRESPCommand(r)
+		if err != nil {
+			return
+		}
+
+		name := strings.ToUpper(cmd[0])
+		if !authenticated && name != "AUTH" {
+			_, _ = io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+
+		f.mu.Lock()
+		switch name {
+		case "PING":
+			_, _ = io.WriteString(conn, "+PONG\r\n")
+		case "AUTH":
+			if len(cmd) == 2 && cmd[1] == f.password {
+				authenticated = true
+				_, _ = io.WriteString(conn, "+OK\r\n")
+			} else {
+				_, _ = io.WriteString(conn, "-ERR invalid password\r\n")
+			}
+		case "SELECT":
+			_, _ = io.WriteString(conn, "+OK\r\n")
+		case "SET":
+			f.keys[cmd[1]] = cmd[2]
+			_, _ = io.WriteString(conn, "+OK\r\n")
+		case "GET":
+			if v, ok := f.keys[cmd[1]]; ok {
+				_, _ = fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+			} else {
+				_, _ = io.WriteString(conn, "$-1\r\n")
+			}
+		case "DEL":
+			n := 0
+			for _, k := range cmd[1:] {
+				if _, ok := f.keys[k]; ok {
+					delete(f.keys, k)
+					n++
+				}
+			}
+			_, _ = fmt.Fprintf(conn, ":%d\r\n", n)
+		default:
+			_, _ = fmt.Fprintf(conn, "-ERR unknown command %s\r\n", name)
+		}
+		f.mu.Unlock()
+	}
+}
+
+func (f *fakeRedis) has(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.keys[key]
+	return ok
+}
+
+// readRESPCommand reads a command sent as a RESP array of bulk strings.
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid command %q", line)
+	}
+
+	cmd := make([]string, n)
+	for i := range cmd {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+
+		cmd[i] = string(buf[:size])
+	}
+
+	return cmd, nil
+}
+
+func Test_redis_ChallengeStore(t *testing.T) {
+	f := newFakeRedis(t, "hunter2")
+	r := newRedis(RedisOptions{Password: "hunter2", DB: 3, KeyPrefix: "myapp:"}, f.addr())
+	ctx := context.Background()
+
+	require.NoError(t, r.Ping(ctx))
+
+	_, err := r.Get(ctx, "key")
+	require.Equal(t, ErrEntryNotFound, err)
+
+	require.NoError(t, r.Set(ctx, "key", []byte("value"), time.Minute))
+	require.True(t, f.has("myapp:key"))
+
+	v, err := r.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), v)
+
+	require.NoError(t, r.Delete(ctx, "key"))
+	require.Equal(t, ErrEntryNotFound, r.Delete(ctx, "key"))
+	require.False(t, f.has("myapp:key"))
+
+	// wrong credentials
+	r = newRedis(RedisOptions{Password: "wrong"}, f.addr())
+	require.Error(t, r.Ping(ctx))
+}
+
+func TestRedisOptions_client(t *testing.T) {
+	prebuilt := redisClient.NewClient(&redisClient.Options{Addr: "prebuilt:6379"})
+
+	tests := []struct {
+		name     string
+		opts     RedisOptions
+		wantType interface{}
+		wantAddr string
+	}{
+		{"default address", RedisOptions{}, &redisClient.Client{}, "default:6379"},
+		{"single server", RedisOptions{Addrs: []string{"server:6379"}}, &redisClient.Client{}, "server:6379"},
+		{"sentinel", RedisOptions{Addrs: []string{"sentinel:26379"}, MasterName: "master"}, &redisClient.Client{}, "FailoverClient"},
+		{"cluster", RedisOptions{Addrs: []string{"node:6379"}, Cluster: true}, &redisClient.ClusterClient{}, ""},
+		{"prebuilt client", RedisOptions{Client: prebuilt, Addrs: []string{"ignored:6379"}}, &redisClient.Client{}, "prebuilt:6379"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.opts.client("default:6379")
+			defer c.Close()
+
+			require.IsType(t, tt.wantType, c)
+			if sc, ok := c.(*redisClient.Client); ok {
+				require.Equal(t, tt.wantAddr, sc.Options().Addr)
+			}
+		})
+	}
+}
+
+func TestRedisOptions_validate(t *testing.T) {
+	require.NoError(t, RedisOptions{}.validate())
+	require.NoError(t, RedisOptions{MasterName: "master", DB: 2}.validate())
+	require.Error(t, RedisOptions{MasterName: "master", Cluster: true}.validate())
+	require.Error(t, RedisOptions{Cluster: true, DB: 2}.validate())
+}
+
+func TestConfigure_redisPing(t *testing.T) {
+	config := func(opts RedisOptions) Config {
+		return Config{
+			JWTSecret: "secret",
+			ProtectedPaths: []ProtectedMapping{
+				{
+					Methods: []string{http.MethodGet},
+					Path:    "/get",
+					Handler: nil,
+				},
+			},
+			CacheType:    CacheTypeRedis,
+			RedisOptions: opts,
+		}
+	}
+
+	f := newFakeRedis(t, "hunter2")
+	require.NoError(t, Configure(config(RedisOptions{Addrs: []string{f.addr()}, Password: "hunter2"}), mux.NewRouter()))
+
+	err := Configure(config(RedisOptions{Addrs: []string{f.addr()}, Password: "wrong"}), mux.NewRouter())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not reach redis")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	unreachable := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	err = Configure(config(RedisOptions{Addrs: []string{unreachable}, DialTimeout: time.Second}), mux.NewRouter())
+	require.Error(t, err)
+}
@@ -1,44 +1,44 @@
 package didcomauth
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // cache_test is just a cache_mem with a flag which returns error if needed
 
 type cTest struct {
-	mem
+	*MemoryStore
 	shouldError bool
 }
 
 var ctError = errors.New("error!")
 
-// newMem returns a new instance of mem with an in-memory map as backing store, typically used for testing.
-func newCTest(shouldError bool) cache {
-	return cache(
+// newCTest returns a new instance of MemoryStore with an in-memory map as backing store, typically used for testing.
+func newCTest(shouldError bool) ChallengeStore {
+	return ChallengeStore(
 		cTest{
-			mem{store: make(map[string]Challenge)},
+			NewMemoryStore(MemoryOptions{SweepInterval: -1}),
 			shouldError,
 		},
 	)
 }
 
-// Set implements the cache interface for redis.
-func (m cTest) Set(c Challenge) error {
+// Set implements the ChallengeStore interface.
+func (m cTest) Set(ctx context.Context, key string, value []byte, expiry time.Duration) error {
 	if m.shouldError {
 		return ctError
 	}
 
-	m.store[getKey(c.DID)] = c
-	return nil
+	return m.MemoryStore.Set(ctx, key, value, expiry)
 }
 
-// Get implements the cache interface for redis.
-func (m cTest) Get(did string) (Challenge, error) {
+// Get implements the ChallengeStore interface.
+func (m cTest) Get(ctx context.Context, key string) ([]byte, error) {
 	if m.shouldError {
-		return Challenge{}, ctError
+		return nil, ctError
 	}
-	return m.store[getKey(did)], nil
-}
 
-func (m cTest) Delete(did string) {
-	delete(m.store, did)
+	return m.MemoryStore.Get(ctx, key)
 }
@@ -28,7 +28,7 @@ func (r *router) challengeGETHandler(rw http.ResponseWriter, req *http.Request)
 		DID:       did,
 	}
 
-	err = r.cp.Set(c)
+	err = setChallenge(req.Context(), r.cp, c)
 
 	if err != nil {
 		log.Println(err)
@@ -1,9 +1,6 @@
 package didcomauth
 
 import (
-	"crypto"
-	"crypto/rsa"
-	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,25 +15,34 @@ func (r *router) challengePOSTHandler(rw http.ResponseWriter, req *http.Request)
 	did := req.Header.Get(DIDHeader)
 	resource := req.Header.Get(ResourceHeader)
 
+	if err := r.checkResource(resource); err != nil {
+		writeError(rw, http.StatusForbidden, err)
+		return
+	}
+
 	// do we have a valid challenge for this did?
-	challenge, err := r.cp.Get(did)
+	challenge, err := getChallenge(req.Context(), r.cp, did)
 	if err != nil {
+		if err != ErrEntryNotFound {
+			log.Println(err)
+		}
+
 		writeError(rw, http.StatusBadRequest, errors.New("challenge not found"))
 		return
 	}
 
-	// as soon as we return, delete the challenge
-	defer r.cp.Delete(did)
+	// challenges can only be answered once, if somebody else deleted it first they're answering it right now
+	if err := deleteChallenge(req.Context(), r.cp, did); err != nil {
+		if err != ErrEntryNotFound {
+			log.Println(err)
+		}
 
-	// does the did have a DDO?
-	ddo, err := resolveDDO(r.config.CommercioLCD, did)
-	if err != nil {
-		writeError(rw, http.StatusBadRequest, err)
+		writeError(rw, http.StatusBadRequest, errors.New("challenge not found"))
 		return
 	}
 
-	// does the ddo has a public signing key?
-	ddoKey, err := ddo.SigningPubKey()
+	// does the did have a DDO?
+	ddo, err := r.config.resolver().Resolve(req.Context(), did)
 	if err != nil {
 		writeError(rw, http.StatusBadRequest, err)
 		return
@@ -58,6 +64,16 @@ func (r *router) challengePOSTHandler(rw http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	if err = r.checkGrants(ar.Grants); err != nil {
+		status := http.StatusBadRequest
+		if err == errResourcePatternNotAllowed {
+			status = http.StatusForbidden
+		}
+
+		writeError(rw, status, err)
+		return
+	}
+
 	// check if ar actually contains the challenge data
 	if err = checkRespCacheValidity(ar, challenge); err != nil {
 		writeError(rw, http.StatusForbidden, err)
@@ -71,23 +87,61 @@ func (r *router) challengePOSTHandler(rw http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	phash := sha256.Sum256(ar.SignaturePayload())
+	// does the ddo have the public signing key the response was signed with?
+	ddoKey, err := signingKey(r.config.keySelector(), ddo, ar.KeyID)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
 
-	err = rsa.VerifyPKCS1v15(ddoKey, crypto.SHA256, phash[:], rb)
+	alg, err := signingAlgorithm(ar.Algorithm, ddoKey)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	if !r.config.algorithmAllowed(alg) {
+		writeError(rw, http.StatusForbidden, fmt.Errorf("signature algorithm %s not allowed", alg))
+		return
+	}
+
+	err = verifySignature(alg, ddoKey, ar.SignaturePayload(), rb)
 	if err != nil {
 		writeError(rw, http.StatusForbidden, errors.New("response verification failed"))
 		return
 	}
 
-	token, err := genJWT(resource, did, r.config.JWTSecret)
+	claims := DidComAuthClaims{Resource: resource, DID: did, Grants: ar.Grants}
+
+	// delegated tokens, and their refresh tokens, can't outlive the delegation
+	var refreshExpiry time.Time
+	if ar.Delegation != nil {
+		if err = r.verifyDelegation(req.Context(), did, *ar.Delegation, claimsResources(claims)); err != nil {
+			writeError(rw, http.StatusForbidden, err)
+			return
+		}
+
+		claims.OnBehalfOf = ar.Delegation.Delegator
+		refreshExpiry = ar.Delegation.refreshExpiry(r.config.RefreshTokenLifetime)
+	}
+
+	claims, err = r.authorize(req.Context(), claims)
+	if err != nil {
+		writeAuthorizationError(rw, err)
+		return
+	}
+
+	resp, err := r.releaseTokens(req.Context(), claims, refreshExpiry)
 	if err != nil {
 		log.Println(err)
 		writeError(rw, http.StatusInternalServerError, errors.New("could not generate jwt token"))
 		return
 	}
 
+	r.setTokenCookies(rw, resp)
+
 	jenc := json.NewEncoder(rw)
-	err = jenc.Encode(ReleaseJWTResponse{Token: token})
+	err = jenc.Encode(resp)
 	if err != nil {
 		writeError(rw, http.StatusInternalServerError, fmt.Errorf("could not marshal token, %w", err))
 	}
@@ -102,15 +156,30 @@ func checkRespCacheValidity(ar AuthResponse, c Challenge) error {
 	return nil
 }
 
-func genJWT(resource, did, signingKey string) (string, error) {
-	token := jwt.New(jwt.GetSigningMethod("HS512"))
-	token.Claims = &DidComAuthClaims{
-		StandardClaims: &jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(jwtTokenExpiry).Unix(),
-		},
-		Resource: resource,
-		DID:      did,
+// genJWT signs claims with key, after setting their ID, subject and validity period.
+// Issuer and audience are taken from claims.StandardClaims, if set.
+func genJWT(claims *DidComAuthClaims, lifetime time.Duration, key JWTKey) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.New(key.method())
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
 	}
 
-	return token.SignedString([]byte(signingKey))
+	if claims.StandardClaims == nil {
+		claims.StandardClaims = &jwt.StandardClaims{}
+	}
+
+	now := time.Now()
+	claims.Id = jti
+	claims.Subject = claims.DID
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+	claims.ExpiresAt = now.Add(lifetime).Unix()
+	token.Claims = claims
+
+	return token.SignedString(key.Key)
 }
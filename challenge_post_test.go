@@ -2,13 +2,23 @@ package didcomauth
 
 import (
 	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	idKeeper "github.com/commercionetwork/commercionetwork/x/id/keeper"
+	"github.com/commercionetwork/commercionetwork/x/id/types"
 
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/jarcoal/httpmock"
 
 	"github.com/dgrijalva/jwt-go"
@@ -89,7 +99,7 @@ func Test_genJWT(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := genJWT("resource", "did", "key")
+			got, err := genJWT(&DidComAuthClaims{Resource: "resource", DID: "did"}, time.Minute, JWTKey{Algorithm: AlgorithmHS512, Key: []byte("key")})
 			require.NoError(t, err)
 
 			token, tokenError := jwt.Parse(got, func(token *jwt.Token) (interface{}, error) {
@@ -138,6 +148,46 @@ func Test_router_challengePOSTHandler(t *testing.T) {
 
 	okayDidDocument := testDidDocument()
 
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	edDidDocument := testDidDocument()
+	edDidDocument.PubKeys[1].Type = KeyTypeEd25519
+	edDidDocument.PubKeys[1].PublicKeyPem = testKeyPEM(t, edPub)
+	edResponse := base64.StdEncoding.EncodeToString(ed25519.Sign(edPriv, pChallenge.SignaturePayload()))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	rsaDidDocument := testDidDocument()
+	rsaDidDocument.PubKeys[1].PublicKeyPem = testKeyPEM(t, &rsaKey.PublicKey)
+	pHash := sha256.Sum256(pChallenge.SignaturePayload())
+	pssSig, err := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, pHash[:], nil)
+	require.NoError(t, err)
+	pssResponse := base64.StdEncoding.EncodeToString(pssSig)
+
+	kidDidDocument := testDidDocument()
+	kidDidDocument.PubKeys = append(kidDidDocument.PubKeys, types.PubKey{
+		ID:           kidDidDocument.ID.String() + "#keys-3",
+		Type:         KeyTypeEd25519,
+		Controller:   kidDidDocument.ID,
+		PublicKeyPem: testKeyPEM(t, edPub),
+	})
+
+	standInConfig := okayConfig
+	standInConfig.Resolver = DIDResolverFunc(func(ctx context.Context, did string) (DIDDocument, error) {
+		return DIDDocument{
+			ID: did,
+			VerificationMethod: []VerificationMethod{
+				{ID: "#auth", Type: KeyTypeEd25519, PublicKeyBase58: base58.Encode(edPub)},
+			},
+			Authentication: []VerificationRelationship{{Reference: "#auth"}},
+		}, nil
+	})
+
+	pssOnlyConfig := okayConfig
+	pssOnlyConfig.AllowedAlgorithms = []string{AlgorithmPS256}
+
 	tests := []struct {
 		name               string
 		responder          httpmock.Responder
@@ -253,6 +303,136 @@ func Test_router_challengePOSTHandler(t *testing.T) {
 			"response verification failed",
 			pChallenge,
 		},
+		{
+			"ddo with ed25519 key, response signed with it",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, ddoResolveResponse{
+				Result: idKeeper.ResolveIdentityResponse{
+					DidDocument: &edDidDocument,
+				}}),
+			okayConfig,
+			false,
+			AuthResponse{
+				Challenge: pChallenge,
+				Response:  edResponse,
+			},
+			http.StatusOK,
+			"token",
+			pChallenge,
+		},
+		{
+			"ddo resolved by a custom resolver",
+			nil,
+			standInConfig,
+			false,
+			AuthResponse{
+				Challenge: pChallenge,
+				Response:  edResponse,
+			},
+			http.StatusOK,
+			"token",
+			pChallenge,
+		},
+		{
+			"response signed with the key picked by kid",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, ddoResolveResponse{
+				Result: idKeeper.ResolveIdentityResponse{
+					DidDocument: &kidDidDocument,
+				}}),
+			okayConfig,
+			false,
+			AuthResponse{
+				Challenge: pChallenge,
+				Response:  edResponse,
+				KeyID:     "#keys-3",
+			},
+			http.StatusOK,
+			"token",
+			pChallenge,
+		},
+		{
+			"kid not in ddo",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, ddoResolveResponse{
+				Result: idKeeper.ResolveIdentityResponse{
+					DidDocument: &kidDidDocument,
+				}}),
+			okayConfig,
+			false,
+			AuthResponse{
+				Challenge: pChallenge,
+				Response:  edResponse,
+				KeyID:     "#keys-4",
+			},
+			http.StatusBadRequest,
+			"can't be used to authenticate",
+			pChallenge,
+		},
+		{
+			"rsa pss response",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, ddoResolveResponse{
+				Result: idKeeper.ResolveIdentityResponse{
+					DidDocument: &rsaDidDocument,
+				}}),
+			pssOnlyConfig,
+			false,
+			AuthResponse{
+				Challenge: pChallenge,
+				Response:  pssResponse,
+				Algorithm: AlgorithmPS256,
+			},
+			http.StatusOK,
+			"token",
+			pChallenge,
+		},
+		{
+			"rsa pss response without algorithm is verified as pkcs1v15",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, ddoResolveResponse{
+				Result: idKeeper.ResolveIdentityResponse{
+					DidDocument: &rsaDidDocument,
+				}}),
+			okayConfig,
+			false,
+			AuthResponse{
+				Challenge: pChallenge,
+				Response:  pssResponse,
+			},
+			http.StatusForbidden,
+			"response verification failed",
+			pChallenge,
+		},
+		{
+			"algorithm not allowed",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, ddoResolveResponse{
+				Result: idKeeper.ResolveIdentityResponse{
+					DidDocument: &rsaDidDocument,
+				}}),
+			pssOnlyConfig,
+			false,
+			AuthResponse{
+				Challenge: pChallenge,
+				Response:  pssResponse,
+				Algorithm: AlgorithmRS256,
+			},
+			http.StatusForbidden,
+			"signature algorithm RS256 not allowed",
+			pChallenge,
+		},
+		{
+			"algorithm doesn't match ddo key",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, ddoResolveResponse{
+				Result: idKeeper.ResolveIdentityResponse{
+					DidDocument: &rsaDidDocument,
+				}}),
+			okayConfig,
+			false,
+			AuthResponse{
+				Challenge: pChallenge,
+				Response:  pssResponse,
+				Algorithm: AlgorithmEdDSA,
+			},
+			http.StatusBadRequest,
+			"can't be used with a rsa key",
+			pChallenge,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -268,7 +448,7 @@ func Test_router_challengePOSTHandler(t *testing.T) {
 			}
 
 			if tt.precachedChallenge != (Challenge{}) {
-				_ = r.cp.Set(tt.precachedChallenge)
+				_ = setChallenge(context.Background(), r.cp, tt.precachedChallenge)
 			}
 
 			arb, err := json.Marshal(tt.authResponse)
@@ -12,7 +12,7 @@ import (
 
 const (
 	challengeSize  = 1024             // number of random bytes fetched from crypto source
-	jwtTokenExpiry = 30 * time.Second // seconds after which a JWT token becomes invalid
+	jwtTokenExpiry = 30 * time.Second // default time after which a JWT token becomes invalid
 )
 
 type Challenge struct {
@@ -36,6 +36,20 @@ func (c Challenge) SignaturePayload() []byte {
 type AuthResponse struct {
 	Challenge
 	Response string `json:"response"`
+
+	// Algorithm is the JWA identifier of the algorithm Response was signed with, if empty the default algorithm for
+	// the DDO signing key type is assumed.
+	Algorithm string `json:"alg,omitempty"`
+
+	// KeyID is the ID of the DDO key Response was signed with, if empty Config.KeySelector picks one.
+	KeyID string `json:"kid,omitempty"`
+
+	// Grants lists the resources and methods the token is requested for, if empty the token gives access to the
+	// X-Resource header resource with any method.
+	Grants []Grant `json:"grants,omitempty"`
+
+	// Delegation, if set, lets the DID act on behalf of the delegator DID which signed it.
+	Delegation *Delegation `json:"delegation,omitempty"`
 }
 
 // Validate checks that AuthResponse is valid and does not contains bogus data.
@@ -49,6 +63,8 @@ func (ar AuthResponse) Validate() error {
 		return errors.New("DID field empty")
 	case ar.Timestamp <= 0:
 		return errors.New("timestamp invalid")
+	case ar.Delegation != nil:
+		return ar.Delegation.Validate()
 	default:
 		return nil
 	}
@@ -70,9 +86,45 @@ type DidComAuthClaims struct {
 	*jwt.StandardClaims
 	Resource string `json:"resource"`
 	DID      string `json:"did"`
+
+	// OnBehalfOf is the DID DID acts on behalf of, if the token has been released for a Delegation.
+	OnBehalfOf string `json:"on_behalf_of,omitempty"`
+
+	// Grants lists the resources and methods the token gives access to, if empty it gives access to Resource with any
+	// method.
+	Grants []Grant `json:"grants,omitempty"`
+
+	// Membership is the commercio.network membership level of DID, set by NewMembershipAuthorizer.
+	Membership string `json:"membership,omitempty"`
+
+	// CSRF is the CSRF token requests authenticated by the token cookie must send, set in cookie mode only.
+	CSRF string `json:"csrf,omitempty"`
+
+	// Extra holds the additional claims set by Config.Authorizer.
+	Extra map[string]interface{} `json:"-"`
 }
 
 // ReleaseJWTResponse represents a JSON struct which we return to a caller if the DID authentication is successful.
 type ReleaseJWTResponse struct {
 	Token string `json:"token"`
+
+	// ExpiresIn is the number of seconds Token is valid for.
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+
+	// RefreshToken can be traded once for a new Token on the refresh endpoint, it's empty if refresh tokens are
+	// disabled.
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	// CSRFToken must be sent in the X-CSRF-Token header by state-changing requests authenticated by the token cookie,
+	// it's empty unless the cookie mode is enabled.
+	CSRFToken string `json:"csrf_token,omitempty"`
+}
+
+// principal returns the DID whose permissions apply to the token: OnBehalfOf for delegated tokens, DID otherwise.
+func (c *DidComAuthClaims) principal() string {
+	if c.OnBehalfOf != "" {
+		return c.OnBehalfOf
+	}
+
+	return c.DID
 }
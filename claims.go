@@ -0,0 +1,63 @@
+package didcomauth
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// parseToken verifies bearer and returns its claims.
+// Registered claims are checked by validateClaims rather than by jwt-go, which doesn't tolerate clock skew.
+func (r *router) parseToken(bearer string) (*DidComAuthClaims, error) {
+	claims := &DidComAuthClaims{StandardClaims: &jwt.StandardClaims{}}
+
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(bearer, claims, r.tokenKey)
+	if err != nil || !token.Valid {
+		return nil, invalidTokenError
+	}
+
+	if err := r.validateClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// validateClaims checks the registered claims of c at time now.
+// Tokens must carry exp, and iss and aud if Config.Issuer and Config.Audience are set; sub, nbf and iat are checked
+// when present, since tokens released by older versions don't carry them.
+func (r *router) validateClaims(c *DidComAuthClaims, now time.Time) error {
+	leeway := int64(r.config.ClockSkewLeeway / time.Second)
+	unix := now.Unix()
+
+	switch {
+	case c.ExpiresAt == 0 || unix > c.ExpiresAt+leeway:
+		return invalidTokenError
+	case c.NotBefore != 0 && unix < c.NotBefore-leeway:
+		return invalidTokenError
+	case c.IssuedAt != 0 && unix < c.IssuedAt-leeway:
+		return invalidTokenError
+	case r.config.Issuer != "" && c.Issuer != r.config.Issuer:
+		return invalidTokenError
+	case r.config.Audience != "" && c.Audience != r.config.Audience:
+		return invalidTokenError
+	case c.Subject != "" && c.Subject != c.DID:
+		return invalidTokenError
+	}
+
+	return nil
+}
+
+// lifetimeAllows returns true if c has been issued within the TokenLifetime of the ProtectedMapping serving path with
+// method at time now, if it has one. Tokens released for resource patterns live as long as the shortest lifetime
+// among the mappings of their grants, which can be longer than the one of the mapping they're used on.
+func (r *router) lifetimeAllows(c *DidComAuthClaims, method, path string, now time.Time) bool {
+	m, ok := r.mappingFor(path, method)
+	if !ok || m.TokenLifetime <= 0 {
+		return true
+	}
+
+	leeway := int64(r.config.ClockSkewLeeway / time.Second)
+	return c.IssuedAt != 0 && now.Unix() <= c.IssuedAt+int64(m.TokenLifetime/time.Second)+leeway
+}
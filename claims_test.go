@@ -0,0 +1,138 @@
+package didcomauth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_router_validateClaims(t *testing.T) {
+	now := time.Now()
+	r := &router{config: Config{Issuer: "didcomauth", Audience: "upload", ClockSkewLeeway: 10 * time.Second}}
+
+	claims := func(edit func(c *jwt.StandardClaims)) *DidComAuthClaims {
+		c := &jwt.StandardClaims{
+			Issuer:    "didcomauth",
+			Audience:  "upload",
+			Subject:   "did",
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(time.Minute).Unix(),
+		}
+		edit(c)
+
+		return &DidComAuthClaims{StandardClaims: c, Resource: "/path", DID: "did"}
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(c *jwt.StandardClaims)
+		wantErr bool
+	}{
+		{"valid", func(c *jwt.StandardClaims) {}, false},
+		{"no exp", func(c *jwt.StandardClaims) { c.ExpiresAt = 0 }, true},
+		{"expired", func(c *jwt.StandardClaims) { c.ExpiresAt = now.Add(-time.Minute).Unix() }, true},
+		{"expired within leeway", func(c *jwt.StandardClaims) { c.ExpiresAt = now.Add(-5 * time.Second).Unix() }, false},
+		{"not yet valid", func(c *jwt.StandardClaims) { c.NotBefore = now.Add(time.Minute).Unix() }, true},
+		{"not yet valid within leeway", func(c *jwt.StandardClaims) { c.NotBefore = now.Add(5 * time.Second).Unix() }, false},
+		{"issued in the future", func(c *jwt.StandardClaims) { c.IssuedAt = now.Add(time.Minute).Unix() }, true},
+		{"wrong issuer", func(c *jwt.StandardClaims) { c.Issuer = "other" }, true},
+		{"no issuer", func(c *jwt.StandardClaims) { c.Issuer = "" }, true},
+		{"wrong audience", func(c *jwt.StandardClaims) { c.Audience = "download" }, true},
+		{"subject isn't the DID", func(c *jwt.StandardClaims) { c.Subject = "another did" }, true},
+		{"no subject, nbf and iat", func(c *jwt.StandardClaims) { c.Subject, c.NotBefore, c.IssuedAt = "", 0, 0 }, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.validateClaims(claims(tt.edit), now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+
+	// issuer and audience aren't checked if not configured
+	r = &router{}
+	require.NoError(t, r.validateClaims(claims(func(c *jwt.StandardClaims) { c.Issuer, c.Audience = "other", "other" }), now))
+}
+
+func Test_router_lifetimeAllows(t *testing.T) {
+	now := time.Now()
+	r := &router{config: Config{
+		ProtectedBasePath: "/protected",
+		ProtectedPaths: []ProtectedMapping{
+			{Methods: []string{http.MethodGet}, Path: "/upload/{id}", TokenLifetime: 5 * time.Second},
+			{Methods: []string{http.MethodGet}, Path: "/profile"},
+		},
+		ResourcePatterns: []string{"/protected/upload/*"},
+		ClockSkewLeeway:  2 * time.Second,
+	}}
+
+	issued := func(ago time.Duration) *DidComAuthClaims {
+		c := &DidComAuthClaims{StandardClaims: &jwt.StandardClaims{ExpiresAt: now.Add(time.Hour).Unix()}}
+		if ago >= 0 {
+			c.IssuedAt = now.Add(-ago).Unix()
+		}
+
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		claims *DidComAuthClaims
+		path   string
+		want   bool
+	}{
+		{"within the mapping lifetime", issued(3 * time.Second), "/protected/upload/12", true},
+		{"within leeway", issued(6 * time.Second), "/protected/upload/12", true},
+		{"older than the mapping lifetime", issued(time.Minute), "/protected/upload/12", false},
+		{"no iat", issued(-1), "/protected/upload/12", false},
+		{"mapping without lifetime", issued(time.Minute), "/protected/profile", true},
+		{"no mapping", issued(-1), "/protected/other", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, r.lifetimeAllows(tt.claims, http.MethodGet, tt.path, now))
+		})
+	}
+}
+
+func Test_router_parseToken(t *testing.T) {
+	key := JWTKey{Algorithm: AlgorithmHS512, Key: []byte("secret")}
+	upload := &router{config: Config{JWTSecret: "secret", Issuer: "didcomauth", Audience: "upload"}}
+	download := &router{config: Config{JWTSecret: "secret", Issuer: "didcomauth", Audience: "download"}}
+
+	token, err := genJWT(&DidComAuthClaims{
+		StandardClaims: &jwt.StandardClaims{Issuer: "didcomauth", Audience: "upload"},
+		Resource:       "/path",
+		DID:            "did",
+	}, time.Minute, key)
+	require.NoError(t, err)
+
+	claims, err := upload.parseToken(token)
+	require.NoError(t, err)
+	require.Equal(t, "did", claims.DID)
+	require.Equal(t, "did", claims.Subject)
+	require.Equal(t, "/path", claims.Resource)
+	require.NotEmpty(t, claims.Id)
+	require.Equal(t, claims.IssuedAt, claims.NotBefore)
+
+	// services sharing the secret don't accept each other's tokens
+	_, err = download.parseToken(token)
+	require.Error(t, err)
+
+	expired, err := genJWT(&DidComAuthClaims{Resource: "/path", DID: "did"}, -time.Minute, key)
+	require.NoError(t, err)
+	_, err = (&router{config: Config{JWTSecret: "secret"}}).parseToken(expired)
+	require.Error(t, err)
+	_, err = (&router{config: Config{JWTSecret: "secret", ClockSkewLeeway: 2 * time.Minute}}).parseToken(expired)
+	require.NoError(t, err)
+}
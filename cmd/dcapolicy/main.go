@@ -0,0 +1,60 @@
+// Command dcapolicy explains whether a didcomauth policy allows a DID to access a resource.
+//
+//	dcapolicy -policy policy.yaml -did did:com:1... -resource /protected/upload/12 [-method GET]
+//
+// Every method is evaluated if -method isn't given. The exit status is 1 if any evaluated method is denied.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/commercionetwork/didcomauth"
+)
+
+var methods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+func main() {
+	policyPath := flag.String("policy", "", "path of the YAML or JSON policy file")
+	did := flag.String("did", "", "DID requesting access")
+	resource := flag.String("resource", "", "path or resource pattern to access")
+	method := flag.String("method", "", "HTTP method, every method if empty")
+	flag.Parse()
+
+	if *policyPath == "" || *did == "" || *resource == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	p, err := didcomauth.LoadPolicy(*policyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	evaluated := methods
+	if *method != "" {
+		evaluated = []string{*method}
+	}
+
+	denied := false
+	for _, m := range evaluated {
+		d := p.Evaluate(*did, m, *resource)
+		fmt.Printf("%-7s %s\n", m, d)
+		denied = denied || !d.Allowed
+	}
+
+	if denied {
+		os.Exit(1)
+	}
+}
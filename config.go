@@ -2,7 +2,9 @@ package didcomauth
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 )
 
 type CacheType int
@@ -25,6 +27,14 @@ type ProtectedMapping struct {
 	Methods []string
 	Path    string
 	Handler http.HandlerFunc
+
+	// TokenLifetime is the lifetime of the tokens released for this mapping, Config.TokenLifetime if zero.
+	TokenLifetime time.Duration
+
+	// MinMembership is the lowest commercio.network membership level, such as MembershipGold, DIDs need to access
+	// this mapping. Any DID can if empty, otherwise the membership must be written in the token by an Authorizer such
+	// as the one NewMembershipAuthorizer returns.
+	MinMembership string
 }
 
 // Config holds data regarding the didcomauth module configuration, such as redis host, Challenge and protected base
@@ -36,7 +46,86 @@ type Config struct {
 	JWTSecret         string
 	CommercioLCD      string
 	CacheType         CacheType
-	CacheProvider     cache
+
+	// JWTSigningKey signs the released tokens, if nil they're signed with HS512 and JWTSecret.
+	// Tokens signed with JWTSecret are still accepted as long as JWTSecret is set.
+	JWTSigningKey *JWTKey
+
+	// JWTVerificationKeys holds keys that don't sign tokens anymore, but whose tokens are still accepted.
+	JWTVerificationKeys []JWTKey
+
+	// TokenLifetime is the lifetime of the released tokens, 30 seconds if zero.
+	TokenLifetime time.Duration
+
+	// RefreshTokenLifetime is the time a client can keep trading refresh tokens for new tokens after authenticating,
+	// without answering a new challenge. Refresh tokens aren't released if zero.
+	RefreshTokenLifetime time.Duration
+
+	// Issuer and Audience are written in the iss and aud claims of the released tokens, and when set only tokens
+	// carrying the same values are accepted. Services sharing signing keys should use distinct audiences.
+	Issuer   string
+	Audience string
+
+	// ClockSkewLeeway is the clock skew tolerated when checking the exp, nbf and iat claims of tokens.
+	ClockSkewLeeway time.Duration
+
+	// ChallengeStore stores issued challenges and the other data didcomauth keeps, if nil a store of CacheType is
+	// used.
+	ChallengeStore ChallengeStore
+
+	// RedisOptions configures the redis store used when CacheType is CacheTypeRedis.
+	RedisOptions RedisOptions
+
+	// MemoryOptions configures the in-memory store used when CacheType is CacheTypeMemory.
+	MemoryOptions MemoryOptions
+
+	// AllowedAlgorithms holds the signature algorithms challenge responses can be signed with, all the supported ones
+	// if empty.
+	AllowedAlgorithms []string
+
+	// KeySelector selects the DDO key challenge responses are verified with, AuthenticationKeySelector if nil.
+	KeySelector KeySelector
+
+	// Authorizer decides what authenticated DIDs can access, if nil they get a token for whatever they request.
+	Authorizer Authorizer
+
+	// Policy is an access-control policy, such as a *Policy or a *PolicyFile, evaluated before Authorizer when
+	// releasing tokens and again on every protected request.
+	Policy PolicySource
+
+	// CookieOptions configures the cookie mode for browser clients, disabled by default.
+	CookieOptions CookieOptions
+
+	// IntrospectionClients maps the IDs of the clients allowed to use the token introspection endpoint to their
+	// secrets, sent with HTTP Basic authentication. The endpoint is only served if not empty.
+	IntrospectionClients map[string]string
+
+	// ResourcePatterns holds the resource patterns clients can request tokens for in the X-Resource header, besides
+	// single paths: prefixes ending with *, such as /protected/upload/*, or mux path templates, such as
+	// /protected/upload/{id}. Tokens released for a pattern give access to every path matching it.
+	ResourcePatterns []string
+
+	// AllowedMethods holds the DID methods clients can authenticate with, only did:com if empty.
+	AllowedMethods []string
+
+	// Resolver resolves DIDs to their DDO.
+	// If nil, did:com DIDs are resolved through the legacy LCD endpoint of the nodes in LCDOptions, did:key DIDs are
+	// resolved locally and did:web ones over HTTPS.
+	Resolver DIDResolver
+
+	// LCDOptions configures how the default resolver reaches the LCD nodes, if LCDOptions.Endpoints is empty
+	// CommercioLCD is the only node queried.
+	LCDOptions HTTPOptions
+
+	// WebResolverOptions configures how the default resolver fetches did:web DID documents: by default only from
+	// public addresses.
+	WebResolverOptions WebResolverOptions
+
+	// DDOCacheTTL is the time resolved DDOs are cached for, 5 minutes if zero.
+	// DDONegativeCacheTTL is the time DIDs without a DDO are remembered as such, 30 seconds if zero.
+	// Negative values disable the respective caching.
+	DDOCacheTTL         time.Duration
+	DDONegativeCacheTTL time.Duration
 }
 
 func (c *Config) Validate() error {
@@ -56,18 +145,134 @@ func (c *Config) Validate() error {
 		return errors.New("no protected paths specificed")
 	}
 
-	if c.JWTSecret == "" {
+	for _, m := range c.ProtectedPaths {
+		if m.MinMembership != "" && membershipRank(m.MinMembership) == 0 {
+			return fmt.Errorf("invalid membership level %s for %s", m.MinMembership, m.Path)
+		}
+	}
+
+	if c.JWTSecret == "" && c.JWTSigningKey == nil {
 		return errors.New("jwt secret is empty")
 	}
 
-	switch c.CacheType {
-	case CacheTypeMemory:
-		c.CacheProvider = newMem()
-	case CacheTypeRedis:
-		c.CacheProvider = newRedis(c.RedisHost)
-	default:
-		return errors.New("cache type not recognized")
+	if c.JWTSigningKey != nil {
+		if err := c.JWTSigningKey.validate(); err != nil {
+			return err
+		}
+	}
+
+	ids := map[string]bool{}
+	if c.JWTSigningKey != nil {
+		ids[c.JWTSigningKey.ID] = true
+	}
+
+	for _, k := range c.JWTVerificationKeys {
+		if err := k.validate(); err != nil {
+			return err
+		}
+
+		if ids[k.ID] {
+			return fmt.Errorf("duplicate jwt key %s", k.ID)
+		}
+
+		ids[k.ID] = true
+	}
+
+	for _, alg := range c.AllowedAlgorithms {
+		if !supportedAlgorithm(alg) {
+			return fmt.Errorf("signature algorithm %s not supported", alg)
+		}
+	}
+
+	for _, pattern := range c.ResourcePatterns {
+		if _, err := newResourcePattern(pattern); err != nil {
+			return err
+		}
+	}
+
+	if len(c.AllowedMethods) == 0 {
+		c.AllowedMethods = defaultMethods
+	}
+
+	for _, m := range c.AllowedMethods {
+		if _, _, err := didMethod("did:" + m + ":x"); err != nil {
+			return fmt.Errorf("invalid DID method %s", m)
+		}
+
+		if c.Resolver == nil && m != MethodCom && m != MethodKey && m != MethodWeb {
+			return fmt.Errorf("DID method %s needs a custom resolver", m)
+		}
+	}
+
+	if c.DDOCacheTTL == 0 {
+		c.DDOCacheTTL = defaultDDOCacheTTL
+	}
+
+	if c.DDONegativeCacheTTL == 0 {
+		c.DDONegativeCacheTTL = defaultDDONegativeTTL
+	}
+
+	if c.ChallengeStore == nil {
+		switch c.CacheType {
+		case CacheTypeMemory:
+			c.ChallengeStore = NewMemoryStore(c.MemoryOptions)
+		case CacheTypeRedis:
+			if err := c.RedisOptions.validate(); err != nil {
+				return err
+			}
+
+			c.ChallengeStore = newRedis(c.RedisOptions, c.RedisHost)
+		default:
+			return errors.New("cache type not recognized")
+		}
+	}
+
+	if _, cached := c.Resolver.(*cachingResolver); !cached {
+		c.Resolver = newCachingResolver(c.resolver(), c.ChallengeStore, c.DDOCacheTTL, c.DDONegativeCacheTTL)
 	}
 
 	return nil
 }
+
+// resolver returns the configured DIDResolver, or one resolving did:com DIDs through the LCD nodes and did:key and
+// did:web ones on its own.
+func (c Config) resolver() DIDResolver {
+	if c.Resolver == nil {
+		opts := c.LCDOptions
+		if len(opts.Endpoints) == 0 {
+			opts.Endpoints = []string{c.CommercioLCD}
+		}
+
+		return MethodResolver{
+			MethodCom: NewLCDResolver(opts),
+			MethodKey: NewKeyResolver(),
+			MethodWeb: NewWebResolver(c.WebResolverOptions),
+		}
+	}
+
+	return c.Resolver
+}
+
+// keySelector returns the configured KeySelector, or the default one.
+func (c Config) keySelector() KeySelector {
+	if c.KeySelector == nil {
+		return AuthenticationKeySelector{}
+	}
+
+	return c.KeySelector
+}
+
+// algorithmAllowed returns true if challenge responses can be signed with alg.
+func (c Config) algorithmAllowed(alg string) bool {
+	if len(c.AllowedAlgorithms) == 0 {
+		return supportedAlgorithm(alg)
+	}
+
+	for _, a := range c.AllowedAlgorithms {
+		if a == alg {
+			return true
+		}
+	}
+
+	return false
+}
@@ -75,6 +75,87 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"unsupported signature algorithm",
+			Config{
+				JWTSecret: "secret",
+				ProtectedPaths: []ProtectedMapping{
+					{
+						Methods: []string{http.MethodGet},
+						Path:    "/get",
+						Handler: nil,
+					},
+				},
+				CacheType:         CacheTypeMemory,
+				AllowedAlgorithms: []string{AlgorithmPS256, "HS256"},
+			},
+			true,
+		},
+		{
+			"builtin DID methods",
+			Config{
+				JWTSecret: "secret",
+				ProtectedPaths: []ProtectedMapping{
+					{
+						Methods: []string{http.MethodGet},
+						Path:    "/get",
+						Handler: nil,
+					},
+				},
+				CacheType:      CacheTypeMemory,
+				AllowedMethods: []string{MethodCom, MethodKey, MethodWeb},
+			},
+			false,
+		},
+		{
+			"custom DID method without a resolver",
+			Config{
+				JWTSecret: "secret",
+				ProtectedPaths: []ProtectedMapping{
+					{
+						Methods: []string{http.MethodGet},
+						Path:    "/get",
+						Handler: nil,
+					},
+				},
+				CacheType:      CacheTypeMemory,
+				AllowedMethods: []string{MethodCom, "example"},
+			},
+			true,
+		},
+		{
+			"custom DID method with a resolver",
+			Config{
+				JWTSecret: "secret",
+				ProtectedPaths: []ProtectedMapping{
+					{
+						Methods: []string{http.MethodGet},
+						Path:    "/get",
+						Handler: nil,
+					},
+				},
+				CacheType:      CacheTypeMemory,
+				AllowedMethods: []string{"example"},
+				Resolver:       NewUniversalResolver(HTTPOptions{Endpoints: []string{"http://localhost:8080"}}),
+			},
+			false,
+		},
+		{
+			"malformed DID method",
+			Config{
+				JWTSecret: "secret",
+				ProtectedPaths: []ProtectedMapping{
+					{
+						Methods: []string{http.MethodGet},
+						Path:    "/get",
+						Handler: nil,
+					},
+				},
+				CacheType:      CacheTypeMemory,
+				AllowedMethods: []string{"did:com"},
+			},
+			true,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -88,3 +169,142 @@ func TestConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_algorithmAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		alg     string
+		want    bool
+	}{
+		{"no restrictions, supported algorithm", nil, AlgorithmPS256, true},
+		{"no restrictions, unsupported algorithm", nil, "HS256", false},
+		{"algorithm allowed", []string{AlgorithmPS256}, AlgorithmPS256, true},
+		{"algorithm not allowed", []string{AlgorithmPS256}, AlgorithmRS256, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{AllowedAlgorithms: tt.allowed}
+			require.Equal(t, tt.want, c.algorithmAllowed(tt.alg))
+		})
+	}
+}
+
+func TestConfig_Validate_ddoCache(t *testing.T) {
+	c := Config{
+		JWTSecret: "secret",
+		ProtectedPaths: []ProtectedMapping{
+			{
+				Methods: []string{http.MethodGet},
+				Path:    "/get",
+				Handler: nil,
+			},
+		},
+		CacheType: CacheTypeMemory,
+	}
+
+	require.NoError(t, c.Validate())
+	require.Equal(t, defaultDDOCacheTTL, c.DDOCacheTTL)
+	require.Equal(t, defaultDDONegativeTTL, c.DDONegativeCacheTTL)
+
+	cr, ok := c.Resolver.(*cachingResolver)
+	require.True(t, ok)
+
+	// validating again doesn't wrap the resolver twice
+	require.NoError(t, c.Validate())
+	require.Equal(t, cr.next, c.Resolver.(*cachingResolver).next)
+}
+
+func TestConfig_Validate_challengeStore(t *testing.T) {
+	store := NewMemoryStore(MemoryOptions{SweepInterval: -1})
+	c := Config{
+		JWTSecret: "secret",
+		ProtectedPaths: []ProtectedMapping{
+			{
+				Methods: []string{http.MethodGet},
+				Path:    "/get",
+				Handler: nil,
+			},
+		},
+		CacheType:      CacheTypeRedis,
+		ChallengeStore: store,
+	}
+
+	// a caller-supplied store is used as-is, for challenges and cached DDOs alike
+	require.NoError(t, c.Validate())
+	require.Equal(t, store, c.ChallengeStore)
+	require.Equal(t, store, c.Resolver.(*cachingResolver).store)
+
+	c.ChallengeStore = nil
+	c.CacheType = CacheTypeMemory
+	require.NoError(t, c.Validate())
+	require.IsType(t, &MemoryStore{}, c.ChallengeStore)
+}
+
+func TestConfig_Validate_jwtSigningKey(t *testing.T) {
+	keys := testJWTKeys(t)
+	config := func(secret string, key *JWTKey) Config {
+		return Config{
+			JWTSecret:     secret,
+			JWTSigningKey: key,
+			ProtectedPaths: []ProtectedMapping{
+				{
+					Methods: []string{http.MethodGet},
+					Path:    "/get",
+					Handler: nil,
+				},
+			},
+			CacheType: CacheTypeMemory,
+		}
+	}
+
+	c := config("", &keys[3])
+	require.NoError(t, c.Validate())
+
+	c = config("secret", &JWTKey{ID: "k", Algorithm: AlgorithmEdDSA, Key: keys[0].Key})
+	require.Error(t, c.Validate())
+
+	c = config("", &keys[3])
+	c.JWTVerificationKeys = []JWTKey{keys[0], keys[4]}
+	require.NoError(t, c.Validate())
+
+	c.JWTVerificationKeys = []JWTKey{keys[0], {ID: "k", Algorithm: AlgorithmHS512}}
+	require.Error(t, c.Validate(), "invalid verification key")
+
+	c.JWTVerificationKeys = []JWTKey{keys[0], keys[3]}
+	require.Error(t, c.Validate(), "duplicate key ID")
+}
+
+func TestConfig_Validate_resourcePatterns(t *testing.T) {
+	c := Config{
+		JWTSecret: "secret",
+		ProtectedPaths: []ProtectedMapping{
+			{
+				Methods: []string{http.MethodGet},
+				Path:    "/get",
+				Handler: nil,
+			},
+		},
+		CacheType:        CacheTypeMemory,
+		ResourcePatterns: []string{"/protected/upload/*", "/protected/user/{id:[0-9]+}"},
+	}
+	require.NoError(t, c.Validate())
+
+	c.ResourcePatterns = []string{"/protected/user/{id"}
+	require.Error(t, c.Validate())
+}
+
+func TestConfig_Validate_minMembership(t *testing.T) {
+	c := Config{
+		JWTSecret: "secret",
+		ProtectedPaths: []ProtectedMapping{
+			{Methods: []string{http.MethodGet}, Path: "/get", MinMembership: MembershipGold},
+		},
+		CacheType: CacheTypeMemory,
+	}
+	require.NoError(t, c.Validate())
+
+	c.ProtectedPaths[0].MinMembership = "platinum"
+	require.Error(t, c.Validate())
+}
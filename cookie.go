@@ -0,0 +1,146 @@
+package didcomauth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultTokenCookieName = "didcomauth_token"
+	defaultCSRFCookieName  = "didcomauth_csrf"
+	defaultCookiePath      = "/"
+
+	// CSRFHeader is the header carrying the CSRF token, required along with the token cookie by state-changing
+	// requests.
+	CSRFHeader = "X-CSRF-Token"
+)
+
+var invalidCSRFTokenError = errors.New("invalid CSRF token")
+
+// CookieOptions configures the cookie mode, meant for browser clients: tokens are also set in a Secure, HttpOnly
+// cookie, which protected handlers accept in place of the Authorization header.
+//
+// Requests authenticated by the cookie can omit the X-DID and X-Resource headers, while state-changing ones (any
+// method but GET, HEAD, OPTIONS and TRACE) must send the CSRF token released along with the token in the
+// X-CSRF-Token header. The CSRF token is also set in a cookie readable by scripts.
+type CookieOptions struct {
+	// Enabled enables the cookie mode.
+	Enabled bool
+
+	// Name is the name of the token cookie, didcomauth_token if empty, and CSRFName the name of the CSRF token
+	// cookie, didcomauth_csrf if empty.
+	Name     string
+	CSRFName string
+
+	// Domain and Path scope the cookies, Path is / if empty.
+	Domain string
+	Path   string
+
+	// SameSite is the SameSite attribute of the cookies, http.SameSiteLaxMode if zero.
+	SameSite http.SameSite
+
+	// Insecure allows the cookies to be sent over plain HTTP, for local development only.
+	Insecure bool
+}
+
+func (o CookieOptions) name() string {
+	if o.Name == "" {
+		return defaultTokenCookieName
+	}
+
+	return o.Name
+}
+
+func (o CookieOptions) csrfName() string {
+	if o.CSRFName == "" {
+		return defaultCSRFCookieName
+	}
+
+	return o.CSRFName
+}
+
+// cookie returns a cookie named name, holding value for maxAge seconds.
+func (o CookieOptions) cookie(name, value string, maxAge int, httpOnly bool) *http.Cookie {
+	path := o.Path
+	if path == "" {
+		path = defaultCookiePath
+	}
+
+	sameSite := o.SameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   o.Domain,
+		MaxAge:   maxAge,
+		Secure:   !o.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite,
+	}
+
+	if maxAge > 0 {
+		c.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
+	}
+
+	return c
+}
+
+// setTokenCookies sets the token and CSRF token cookies for resp, if the cookie mode is enabled.
+func (r *router) setTokenCookies(rw http.ResponseWriter, resp ReleaseJWTResponse) {
+	o := r.config.CookieOptions
+	if !o.Enabled {
+		return
+	}
+
+	maxAge := int(resp.ExpiresIn)
+	http.SetCookie(rw, o.cookie(o.name(), resp.Token, maxAge, true))
+	http.SetCookie(rw, o.cookie(o.csrfName(), resp.CSRFToken, maxAge, false))
+}
+
+// clearTokenCookies deletes the token and CSRF token cookies, if the cookie mode is enabled.
+func (r *router) clearTokenCookies(rw http.ResponseWriter) {
+	o := r.config.CookieOptions
+	if !o.Enabled {
+		return
+	}
+
+	http.SetCookie(rw, o.cookie(o.name(), "", -1, true))
+	http.SetCookie(rw, o.cookie(o.csrfName(), "", -1, false))
+}
+
+// requestToken returns the token req is authenticated with, from the Authorization header or, if the header is
+// missing and the cookie mode is enabled, from the token cookie.
+func (r *router) requestToken(req *http.Request) (token string, fromCookie bool) {
+	if ah := req.Header.Get(authHeader); ah != "" || !r.config.CookieOptions.Enabled {
+		return getBearer(ah), false
+	}
+
+	c, err := req.Cookie(r.config.CookieOptions.name())
+	if err != nil {
+		return "", false
+	}
+
+	return c.Value, true
+}
+
+// checkCSRF returns an error if req is a state-changing request without the CSRF token released along with the
+// token claims belong to.
+func checkCSRF(req *http.Request, claims *DidComAuthClaims) error {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+
+	header := req.Header.Get(CSRFHeader)
+	if claims.CSRF == "" || subtle.ConstantTimeCompare([]byte(header), []byte(claims.CSRF)) != 1 {
+		return invalidCSRFTokenError
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookieOptions_cookie(t *testing.T) {
+	c := CookieOptions{}.cookie("name", "value", 60, true)
+	require.Equal(t, "/", c.Path)
+	require.True(t, c.Secure)
+	require.True(t, c.HttpOnly)
+	require.Equal(t, http.SameSiteLaxMode, c.SameSite)
+	require.WithinDuration(t, time.Now().Add(time.Minute), c.Expires, 2*time.Second)
+
+	o := CookieOptions{Path: "/protected", Domain: "example.com", SameSite: http.SameSiteStrictMode, Insecure: true}
+	c = o.cookie("name", "", -1, false)
+	require.Equal(t, "/protected", c.Path)
+	require.Equal(t, "example.com", c.Domain)
+	require.False(t, c.Secure)
+	require.False(t, c.HttpOnly)
+	require.Equal(t, http.SameSiteStrictMode, c.SameSite)
+	require.Equal(t, -1, c.MaxAge)
+}
+
+func Test_checkCSRF(t *testing.T) {
+	claims := &DidComAuthClaims{CSRF: "csrf"}
+
+	tests := []struct {
+		name    string
+		method  string
+		header  string
+		claims  *DidComAuthClaims
+		wantErr bool
+	}{
+		{"safe method", http.MethodGet, "", claims, false},
+		{"state-changing method with token", http.MethodPost, "csrf", claims, false},
+		{"state-changing method without token", http.MethodPost, "", claims, true},
+		{"state-changing method with wrong token", http.MethodDelete, "other", claims, true},
+		{"token without CSRF claim", http.MethodPut, "", &DidComAuthClaims{}, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/path", nil)
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+
+			err := checkCSRF(req, tt.claims)
+			if tt.wantErr {
+				require.Equal(t, invalidCSRFTokenError, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_cookieMode(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{
+		config: Config{JWTSecret: "secret", RefreshTokenLifetime: time.Hour, CookieOptions: CookieOptions{Enabled: true}},
+		cp:     newCTest(false),
+	}
+
+	first, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: did, Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+	require.NotEmpty(t, first.CSRFToken)
+
+	// refreshing sets the cookies of the new token
+	body, err := json.Marshal(RefreshRequest{RefreshToken: first.RefreshToken})
+	require.NoError(t, err)
+	rr := refreshRequest(t, r, did, "/path", string(body))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp ReleaseJWTResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	cookies := map[string]*http.Cookie{}
+	for _, c := range rr.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	require.Equal(t, resp.Token, cookies[defaultTokenCookieName].Value)
+	require.True(t, cookies[defaultTokenCookieName].HttpOnly)
+	require.Equal(t, resp.CSRFToken, cookies[defaultCSRFCookieName].Value)
+	require.False(t, cookies[defaultCSRFCookieName].HttpOnly)
+
+	protected := checkAuth{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) }),
+		r:    r,
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    int
+	}{
+		{"navigation without headers", http.MethodGet, nil, http.StatusOK},
+		{"matching headers", http.MethodGet, map[string]string{DIDHeader: did, ResourceHeader: "/path"}, http.StatusOK},
+		{"another DID", http.MethodGet, map[string]string{DIDHeader: "did:com:12p24st9asf394jv04e8sxrl9c384jjqwejv0gf"}, http.StatusForbidden},
+		{"state-changing request without CSRF token", http.MethodPost, nil, http.StatusForbidden},
+		{"state-changing request with CSRF token", http.MethodPost, map[string]string{CSRFHeader: resp.CSRFToken}, http.StatusOK},
+		{"Authorization header takes precedence", http.MethodGet, map[string]string{authHeader: "Bearer invalid"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/path", nil)
+			req.AddCookie(cookies[defaultTokenCookieName])
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+
+			rr := httptest.NewRecorder()
+			protected.ServeHTTP(rr, req)
+			require.Equal(t, tt.want, rr.Code)
+		})
+	}
+
+	// the cookie isn't accepted if the cookie mode is disabled
+	r.config.CookieOptions.Enabled = false
+	req := httptest.NewRequest(http.MethodGet, "/path", nil)
+	req.AddCookie(cookies[defaultTokenCookieName])
+	rr = httptest.NewRecorder()
+	protected.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	r.config.CookieOptions.Enabled = true
+
+	// logging out needs the CSRF token, and clears the cookies
+	logout := func(csrf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+		req.AddCookie(cookies[defaultTokenCookieName])
+		if csrf != "" {
+			req.Header.Set(CSRFHeader, csrf)
+		}
+
+		rr := httptest.NewRecorder()
+		r.logoutPOSTHandler(rr, req)
+		return rr
+	}
+
+	require.Equal(t, http.StatusForbidden, logout("").Code)
+
+	rr = logout(resp.CSRFToken)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	for _, c := range rr.Result().Cookies() {
+		require.Empty(t, c.Value)
+		require.True(t, c.MaxAge < 0)
+	}
+}
@@ -0,0 +1,131 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	ddoKeyFmt             = "ddo-%s"
+	defaultDDOCacheTTL    = 5 * time.Minute  // time a resolved DDO is kept in cache
+	defaultDDONegativeTTL = 30 * time.Second // time a DID without DDO is remembered as such
+	ddoResolutionTimeout  = 30 * time.Second // time a coalesced resolution can take
+)
+
+// ddoCacheEntry is the cached outcome of a DID resolution: either a DDO or, if nil, the absence of one.
+type ddoCacheEntry struct {
+	Document *DIDDocument `json:"document,omitempty"`
+}
+
+// cachingResolver is a DIDResolver which caches the resolutions made by next, and coalesces concurrent resolutions
+// of the same DID into a single one.
+type cachingResolver struct {
+	next        DIDResolver
+	store       ChallengeStore
+	ttl         time.Duration
+	negativeTTL time.Duration
+	group       *singleflight.Group
+}
+
+// newCachingResolver returns a cachingResolver which caches DDOs resolved by next in store for ttl, and DIDs without
+// one for negativeTTL.
+func newCachingResolver(next DIDResolver, store ChallengeStore, ttl, negativeTTL time.Duration) *cachingResolver {
+	return &cachingResolver{
+		next:        next,
+		store:       store,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		group:       &singleflight.Group{},
+	}
+}
+
+func getDDOKey(did string) string {
+	return fmt.Sprintf(ddoKeyFmt, did)
+}
+
+// Resolve implements the DIDResolver interface.
+func (cr *cachingResolver) Resolve(ctx context.Context, did string) (DIDDocument, error) {
+	if e, ok := cr.cached(ctx, did); ok {
+		return e.outcome(did)
+	}
+
+	// the resolution is shared by every caller, hence it can't be canceled by the one which started it: it has its
+	// own deadline, and each caller stops waiting when its context is done
+	ch := cr.group.DoChan(did, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), ddoResolutionTimeout)
+		defer cancel()
+
+		doc, err := cr.next.Resolve(ctx, did)
+		switch {
+		case err == nil:
+			cr.cache(ctx, did, ddoCacheEntry{Document: &doc}, cr.ttl)
+		case errors.Is(err, ErrDIDNotFound):
+			cr.cache(ctx, did, ddoCacheEntry{}, cr.negativeTTL)
+		}
+
+		return doc, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return DIDDocument{}, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return DIDDocument{}, res.Err
+		}
+
+		return res.Val.(DIDDocument), nil
+	}
+}
+
+// cached returns the cache entry for did, if any.
+func (cr *cachingResolver) cached(ctx context.Context, did string) (ddoCacheEntry, bool) {
+	b, err := cr.store.Get(ctx, getDDOKey(did))
+	if err != nil {
+		if err != ErrEntryNotFound {
+			log.Println("could not read cached DDO,", err)
+		}
+
+		return ddoCacheEntry{}, false
+	}
+
+	var e ddoCacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		log.Println("could not unmarshal cached DDO,", err)
+		return ddoCacheEntry{}, false
+	}
+
+	return e, true
+}
+
+// cache stores e for did, a cache failure only costs us another resolution hence it's just logged.
+func (cr *cachingResolver) cache(ctx context.Context, did string, e ddoCacheEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		log.Println("could not marshal DDO for caching,", err)
+		return
+	}
+
+	if err := cr.store.Set(ctx, getDDOKey(did), b, ttl); err != nil {
+		log.Println("could not cache DDO,", err)
+	}
+}
+
+// outcome returns the resolution result e represents.
+func (e ddoCacheEntry) outcome(did string) (DIDDocument, error) {
+	if e.Document == nil {
+		return DIDDocument{}, fmt.Errorf("ddo for %s not found, %w", did, ErrDIDNotFound)
+	}
+
+	return *e.Document, nil
+}
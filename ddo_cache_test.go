@@ -0,0 +1,136 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingResolver counts resolutions, answering with err if not nil.
+func countingResolver(calls *int32, delay time.Duration, err error) DIDResolver {
+	return DIDResolverFunc(func(ctx context.Context, did string) (DIDDocument, error) {
+		atomic.AddInt32(calls, 1)
+		time.Sleep(delay)
+
+		if err != nil {
+			return DIDDocument{}, err
+		}
+
+		return DIDDocument{ID: did}, nil
+	})
+}
+
+func Test_cachingResolver_Resolve(t *testing.T) {
+	notFound := fmt.Errorf("ddo for did not found, %w", ErrDIDNotFound)
+
+	tests := []struct {
+		name        string
+		err         error
+		ttl         time.Duration
+		negativeTTL time.Duration
+		wantCalls   int32
+	}{
+		{"ddo is cached", nil, time.Minute, time.Minute, 1},
+		{"missing ddo is cached", notFound, time.Minute, time.Minute, 1},
+		{"resolution errors are not cached", errors.New("lcd down"), time.Minute, time.Minute, 3},
+		{"ddo caching disabled", nil, -1, time.Minute, 3},
+		{"negative caching disabled", notFound, time.Minute, -1, 3},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			cr := newCachingResolver(countingResolver(&calls, 0, tt.err), NewMemoryStore(MemoryOptions{}), tt.ttl, tt.negativeTTL)
+
+			for i := 0; i < 3; i++ {
+				doc, err := cr.Resolve(context.Background(), "did")
+				if tt.err != nil {
+					require.Error(t, err)
+					require.Equal(t, errors.Is(tt.err, ErrDIDNotFound), errors.Is(err, ErrDIDNotFound))
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, "did", doc.ID)
+			}
+
+			require.Equal(t, tt.wantCalls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func Test_cachingResolver_expiry(t *testing.T) {
+	var calls int32
+	cr := newCachingResolver(countingResolver(&calls, 0, nil), NewMemoryStore(MemoryOptions{}), 10*time.Millisecond, time.Minute)
+
+	_, err := cr.Resolve(context.Background(), "did")
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = cr.Resolve(context.Background(), "did")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_cachingResolver_coalescing(t *testing.T) {
+	var calls int32
+	cr := newCachingResolver(countingResolver(&calls, 50*time.Millisecond, nil), NewMemoryStore(MemoryOptions{}), time.Minute, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			doc, err := cr.Resolve(context.Background(), "did")
+			require.NoError(t, err)
+			require.Equal(t, "did", doc.ID)
+		}()
+	}
+
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func Test_cachingResolver_cancellation(t *testing.T) {
+	release := make(chan struct{})
+	cr := newCachingResolver(DIDResolverFunc(func(ctx context.Context, did string) (DIDDocument, error) {
+		select {
+		case <-ctx.Done():
+			return DIDDocument{}, ctx.Err()
+		case <-release:
+			return DIDDocument{ID: did}, nil
+		}
+	}), NewMemoryStore(MemoryOptions{}), time.Minute, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := cr.Resolve(ctx, "did")
+		first <- err
+	}()
+
+	// wait for the first resolution to start before joining it
+	time.Sleep(10 * time.Millisecond)
+	second := make(chan error)
+	go func() {
+		doc, err := cr.Resolve(context.Background(), "did")
+		if err == nil && doc.ID != "did" {
+			err = errors.New("wrong DDO")
+		}
+		second <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	require.Equal(t, context.Canceled, <-first, "the first caller stops waiting")
+
+	close(release)
+	require.NoError(t, <-second, "the resolution goes on for the other callers")
+}
@@ -0,0 +1,187 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Delegation is a statement signed by Delegator, letting Delegate act on its behalf on Resources until ExpiresAt.
+// Clients send it along with the challenge response of Delegate, and get a token carrying Delegator in its
+// on_behalf_of claim.
+type Delegation struct {
+	Delegator string `json:"delegator"`
+	Delegate  string `json:"delegate"`
+
+	// Audience is the service the delegation is valid for, its Config.Audience, or Config.Issuer if it has none.
+	Audience string `json:"audience"`
+
+	// Resources lists the paths and resource patterns, such as /protected/upload/*, Delegate can act on.
+	Resources []string `json:"resources"`
+
+	// ExpiresAt is the Unix time the delegation expires at.
+	ExpiresAt int64 `json:"expires_at"`
+
+	// Signature is the base64-encoded signature of SignaturePayload, made with a key of the Delegator DDO.
+	Signature string `json:"signature"`
+
+	// Algorithm is the JWA identifier of the algorithm Signature was made with, if empty the default algorithm for
+	// the DDO key type is assumed.
+	Algorithm string `json:"alg,omitempty"`
+
+	// KeyID is the ID of the DDO key Signature was made with, if empty Config.KeySelector picks one.
+	KeyID string `json:"kid,omitempty"`
+}
+
+// SignaturePayload returns the bytes the delegator should have placed its signature on: the compact JSON encoding of
+// the delegator, delegate, audience, resources and expires_at fields, in this order.
+func (d Delegation) SignaturePayload() []byte {
+	b, _ := json.Marshal(struct {
+		Delegator string   `json:"delegator"`
+		Delegate  string   `json:"delegate"`
+		Audience  string   `json:"audience"`
+		Resources []string `json:"resources"`
+		ExpiresAt int64    `json:"expires_at"`
+	}{d.Delegator, d.Delegate, d.Audience, d.Resources, d.ExpiresAt})
+
+	return b
+}
+
+// Validate checks that Delegation is valid and does not contains bogus data.
+func (d Delegation) Validate() error {
+	switch {
+	case d.Delegator == "":
+		return errors.New("delegation delegator field empty")
+	case d.Delegate == "":
+		return errors.New("delegation delegate field empty")
+	case d.Audience == "":
+		return errors.New("delegation audience field empty")
+	case len(d.Resources) == 0:
+		return errors.New("delegation resources field empty")
+	case len(d.Resources) > maxGrants:
+		return fmt.Errorf("too many delegated resources, at most %d are allowed", maxGrants)
+	case d.ExpiresAt <= 0:
+		return errors.New("delegation expires_at invalid")
+	case d.Signature == "":
+		return errors.New("delegation signature field empty")
+	}
+
+	for _, resource := range d.Resources {
+		if !strings.HasPrefix(resource, "/") {
+			return fmt.Errorf("delegated resource %s must begin with /", resource)
+		}
+	}
+
+	return nil
+}
+
+// covers returns true if resource, a path or a resource pattern, is one of the delegated resources or matches one of
+// the delegated patterns.
+func (d Delegation) covers(resource string) bool {
+	for _, delegated := range d.Resources {
+		if delegated == resource {
+			return true
+		}
+
+		if !isResourcePattern(delegated) {
+			continue
+		}
+
+		if p, err := newResourcePattern(delegated); err == nil && p.covers(resource) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refreshExpiry returns the time the refresh tokens released with d expire at: when d does, or after
+// refreshLifetime if sooner.
+func (d Delegation) refreshExpiry(refreshLifetime time.Duration) time.Time {
+	expiry := time.Unix(d.ExpiresAt, 0)
+	if refreshLifetime > 0 {
+		if e := time.Now().Add(refreshLifetime); e.Before(expiry) {
+			return e
+		}
+	}
+
+	return expiry
+}
+
+// delegationAudience returns the audience delegations must be issued for: Config.Audience, or Config.Issuer if empty.
+func (c Config) delegationAudience() string {
+	if c.Audience != "" {
+		return c.Audience
+	}
+
+	return c.Issuer
+}
+
+// verifyDelegation checks that d lets did act on resources on behalf of its delegator for this service, that it
+// hasn't expired and that it's signed with a key of the delegator DDO.
+func (r *router) verifyDelegation(ctx context.Context, did string, d Delegation, resources []string) error {
+	audience := r.config.delegationAudience()
+	if audience == "" {
+		return errors.New("delegations not accepted without Config.Audience or Config.Issuer")
+	}
+
+	if d.Audience != audience {
+		return fmt.Errorf("delegation not issued for %s", audience)
+	}
+
+	if d.Delegate != did {
+		return fmt.Errorf("delegation not issued to %s", did)
+	}
+
+	if d.Delegator == did {
+		return errors.New("DIDs can't delegate themselves")
+	}
+
+	if err := checkDID(d.Delegator, r.config.AllowedMethods); err != nil {
+		return err
+	}
+
+	if time.Now().Unix() >= d.ExpiresAt {
+		return errors.New("delegation expired")
+	}
+
+	for _, resource := range resources {
+		if !d.covers(resource) {
+			return fmt.Errorf("delegation doesn't cover %s", resource)
+		}
+	}
+
+	ddo, err := r.config.resolver().Resolve(ctx, d.Delegator)
+	if err != nil {
+		return err
+	}
+
+	key, err := signingKey(r.config.keySelector(), ddo, d.KeyID)
+	if err != nil {
+		return err
+	}
+
+	alg, err := signingAlgorithm(d.Algorithm, key)
+	if err != nil {
+		return err
+	}
+
+	if !r.config.algorithmAllowed(alg) {
+		return fmt.Errorf("signature algorithm %s not allowed", alg)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(d.Signature)
+	if err != nil {
+		return errors.New("delegation signature format invalid")
+	}
+
+	if err := verifySignature(alg, key, d.SignaturePayload(), sig); err != nil {
+		return errors.New("delegation verification failed")
+	}
+
+	return nil
+}
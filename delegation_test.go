@@ -0,0 +1,383 @@
+package didcomauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDelegator = "did:com:12p24st9asf394jv04e8sxrl9c384jjqwejv0gf"
+	testDelegate  = "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+)
+
+// testDelegationKeys returns a resolver for DDOs holding an Ed25519 authentication key each, along with the private
+// keys by DID.
+func testDelegationKeys(t *testing.T, dids ...string) (DIDResolver, map[string]ed25519.PrivateKey) {
+	keys := make(map[string]ed25519.PrivateKey, len(dids))
+	for _, did := range dids {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		keys[did] = priv
+	}
+
+	resolver := DIDResolverFunc(func(_ context.Context, did string) (DIDDocument, error) {
+		priv, ok := keys[did]
+		if !ok {
+			return DIDDocument{}, ErrDIDNotFound
+		}
+
+		return DIDDocument{
+			ID: did,
+			VerificationMethod: []VerificationMethod{
+				{ID: "#auth", Type: KeyTypeEd25519, PublicKeyBase58: base58.Encode(priv.Public().(ed25519.PublicKey))},
+			},
+			Authentication: []VerificationRelationship{{Reference: "#auth"}},
+		}, nil
+	})
+
+	return resolver, keys
+}
+
+// signDelegation returns d signed with key.
+func signDelegation(d Delegation, key ed25519.PrivateKey) Delegation {
+	d.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, d.SignaturePayload()))
+	return d
+}
+
+func TestDelegation_SignaturePayload(t *testing.T) {
+	d := Delegation{
+		Delegator: "did:com:1",
+		Delegate:  "did:com:2",
+		Audience:  "https://api.example.com",
+		Resources: []string{"/protected/upload/*"},
+		ExpiresAt: 1586256784,
+		Signature: "signature",
+		KeyID:     "#auth",
+	}
+
+	require.Equal(t,
+		`{"delegator":"did:com:1","delegate":"did:com:2","audience":"https://api.example.com","resources":["/protected/upload/*"],"expires_at":1586256784}`,
+		string(d.SignaturePayload()),
+	)
+}
+
+func TestDelegation_Validate(t *testing.T) {
+	okay := Delegation{
+		Delegator: "did:com:1",
+		Delegate:  "did:com:2",
+		Audience:  "https://api.example.com",
+		Resources: []string{"/protected/profile"},
+		ExpiresAt: 1586256784,
+		Signature: "signature",
+	}
+
+	tooMany := make([]string, maxGrants+1)
+	for i := range tooMany {
+		tooMany[i] = "/protected/profile"
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(d *Delegation)
+		wantErr bool
+	}{
+		{"okay", func(*Delegation) {}, false},
+		{"no delegator", func(d *Delegation) { d.Delegator = "" }, true},
+		{"no delegate", func(d *Delegation) { d.Delegate = "" }, true},
+		{"no audience", func(d *Delegation) { d.Audience = "" }, true},
+		{"no resources", func(d *Delegation) { d.Resources = nil }, true},
+		{"too many resources", func(d *Delegation) { d.Resources = tooMany }, true},
+		{"relative resource", func(d *Delegation) { d.Resources = []string{"protected/profile"} }, true},
+		{"no expiry", func(d *Delegation) { d.ExpiresAt = 0 }, true},
+		{"no signature", func(d *Delegation) { d.Signature = "" }, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d := okay
+			tt.edit(&d)
+
+			err := d.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDelegation_covers(t *testing.T) {
+	d := Delegation{Resources: []string{"/protected/profile", "/protected/upload/*", "/protected/user/{id}"}}
+
+	require.True(t, d.covers("/protected/profile"))
+	require.True(t, d.covers("/protected/upload/12"))
+	require.True(t, d.covers("/protected/upload/12/*"))
+	require.True(t, d.covers("/protected/user/12"))
+	require.True(t, d.covers("/protected/user/{id}"))
+	require.False(t, d.covers("/protected/profile/12"))
+	require.False(t, d.covers("/protected/*"))
+	require.False(t, d.covers("/protected/user/12/avatar"))
+}
+
+func TestDelegation_refreshExpiry(t *testing.T) {
+	d := Delegation{ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	require.Equal(t, d.ExpiresAt, d.refreshExpiry(0).Unix())
+	require.Equal(t, d.ExpiresAt, d.refreshExpiry(2*time.Hour).Unix())
+	require.InDelta(t, time.Now().Add(time.Minute).Unix(), d.refreshExpiry(time.Minute).Unix(), 2)
+}
+
+func Test_router_verifyDelegation(t *testing.T) {
+	setCosmosConfig()
+
+	resolver, keys := testDelegationKeys(t, testDelegator, testDelegate)
+	audience := "https://api.example.com"
+	r := &router{config: Config{Resolver: resolver, AllowedMethods: defaultMethods, Audience: audience}}
+
+	okay := Delegation{
+		Delegator: testDelegator,
+		Delegate:  testDelegate,
+		Audience:  audience,
+		Resources: []string{"/protected/upload/*"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+
+	tests := []struct {
+		name      string
+		did       string
+		edit      func(d *Delegation)
+		key       ed25519.PrivateKey
+		resources []string
+		wantErr   string
+	}{
+		{"okay", testDelegate, func(*Delegation) {}, keys[testDelegator], []string{"/protected/upload/12"}, ""},
+		{"another delegate", testDelegator, func(*Delegation) {}, keys[testDelegator], nil, "not issued to"},
+		{
+			"another audience",
+			testDelegate,
+			func(d *Delegation) { d.Audience = "https://other.example.com" },
+			keys[testDelegator],
+			nil,
+			"not issued for " + audience,
+		},
+		{
+			"self delegation",
+			testDelegate,
+			func(d *Delegation) { d.Delegator = testDelegate },
+			keys[testDelegate],
+			nil,
+			"can't delegate themselves",
+		},
+		{
+			"method not allowed",
+			testDelegate,
+			func(d *Delegation) { d.Delegator = "did:web:example.com" },
+			keys[testDelegator],
+			nil,
+			"not allowed",
+		},
+		{
+			"expired",
+			testDelegate,
+			func(d *Delegation) { d.ExpiresAt = time.Now().Add(-time.Second).Unix() },
+			keys[testDelegator],
+			nil,
+			"delegation expired",
+		},
+		{
+			"resource not delegated",
+			testDelegate,
+			func(*Delegation) {},
+			keys[testDelegator],
+			[]string{"/protected/upload/12", "/protected/profile"},
+			"doesn't cover /protected/profile",
+		},
+		{
+			"delegator without DDO",
+			testDelegate,
+			func(d *Delegation) { d.Delegator = sdk.AccAddress(make([]byte, 20)).String() },
+			keys[testDelegator],
+			nil,
+			ErrDIDNotFound.Error(),
+		},
+		{"signed by the delegate", testDelegate, func(*Delegation) {}, keys[testDelegate], nil, "verification failed"},
+		{"tampered", testDelegate, nil, keys[testDelegator], nil, "verification failed"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d := okay
+			if tt.edit != nil {
+				tt.edit(&d)
+				d = signDelegation(d, tt.key)
+			} else {
+				// resources widened after signing
+				d = signDelegation(d, tt.key)
+				d.Resources = []string{"/protected/*"}
+			}
+
+			err := r.verifyDelegation(context.Background(), tt.did, d, tt.resources)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+
+	// the issuer is the audience of services without one
+	d := signDelegation(okay, keys[testDelegator])
+	r.config.Audience, r.config.Issuer = "", audience
+	require.NoError(t, r.verifyDelegation(context.Background(), testDelegate, d, nil))
+
+	r.config.Issuer = ""
+	err := r.verifyDelegation(context.Background(), testDelegate, d, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "without Config.Audience or Config.Issuer")
+}
+
+func Test_router_challengePOSTHandler_delegation(t *testing.T) {
+	setCosmosConfig()
+
+	resolver, keys := testDelegationKeys(t, testDelegator, testDelegate)
+	r := &router{
+		config: Config{
+			JWTSecret:            "secret",
+			Resolver:             resolver,
+			AllowedMethods:       defaultMethods,
+			ResourcePatterns:     []string{"/protected/upload/*"},
+			RefreshTokenLifetime: 24 * time.Hour,
+			Audience:             "https://api.example.com",
+		},
+		cp: newCTest(false),
+	}
+
+	delegationExpiry := time.Now().Add(time.Hour).Unix()
+	delegation := signDelegation(Delegation{
+		Delegator: testDelegator,
+		Delegate:  testDelegate,
+		Audience:  "https://api.example.com",
+		Resources: []string{"/protected/upload/*"},
+		ExpiresAt: delegationExpiry,
+	}, keys[testDelegator])
+
+	post := func(resource string, d *Delegation) *httptest.ResponseRecorder {
+		c := Challenge{Challenge: "challenge", Timestamp: time.Now().Unix(), DID: testDelegate}
+		require.NoError(t, setChallenge(context.Background(), r.cp, c))
+
+		b, err := json.Marshal(AuthResponse{
+			Challenge:  c,
+			Response:   base64.StdEncoding.EncodeToString(ed25519.Sign(keys[testDelegate], c.SignaturePayload())),
+			Delegation: d,
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/challenge", bytes.NewReader(b))
+		req.Header.Set(DIDHeader, testDelegate)
+		req.Header.Set(ResourceHeader, resource)
+
+		rr := httptest.NewRecorder()
+		r.challengePOSTHandler(rr, req)
+		return rr
+	}
+
+	// delegated tokens carry both DIDs
+	rr := post("/protected/upload/*", &delegation)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var resp ReleaseJWTResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	claims := rawClaims(resp.Token)
+	require.Equal(t, testDelegate, claims["did"])
+	require.Equal(t, testDelegator, claims["on_behalf_of"])
+
+	// and so do their refresh tokens, which don't outlive the delegation
+	b, err := r.cp.Get(context.Background(), getRefreshKey(resp.RefreshToken))
+	require.NoError(t, err)
+	var grant refreshGrant
+	require.NoError(t, json.Unmarshal(b, &grant))
+	require.Equal(t, testDelegator, grant.OnBehalfOf)
+	require.Equal(t, delegationExpiry, grant.ExpiresAt)
+
+	protected := checkAuth{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			identity, _ := IdentityFromContext(req.Context())
+			_, _ = w.Write([]byte(identity.OnBehalfOf))
+		}),
+		r: r,
+	}
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/protected/upload/12", nil)
+		req.Header.Set(authHeader, "Bearer "+token)
+		req.Header.Set(DIDHeader, testDelegate)
+		req.Header.Set(ResourceHeader, "/protected/upload/*")
+
+		rr := httptest.NewRecorder()
+		protected.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr = get(resp.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, testDelegator, rr.Body.String())
+
+	// resources outside the delegation are refused
+	rr = post("/protected/profile", &delegation)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Contains(t, rr.Body.String(), "doesn't cover")
+
+	// invalid delegations are rejected before being verified
+	invalid := delegation
+	invalid.Signature = ""
+	rr = post("/protected/upload/*", &invalid)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// revoking the delegator revokes the tokens released on its behalf
+	require.NoError(t, r.revokeDID(context.Background(), testDelegator))
+	rr = get(resp.Token)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+
+	body, err := json.Marshal(RefreshRequest{RefreshToken: resp.RefreshToken})
+	require.NoError(t, err)
+	rr = refreshRequest(t, r, testDelegate, "/protected/upload/*", string(body))
+	require.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func Test_router_authorize_delegation(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`
+rules:
+  - effect: allow
+    subjects: ["` + testDelegator + `"]
+    resources: ["/protected/upload/*"]
+`))
+	require.NoError(t, err)
+
+	r := &router{config: Config{Policy: policy}}
+
+	// the policy applies to the DID acting on behalf of the delegator as to the delegator
+	claims := DidComAuthClaims{DID: testDelegate, OnBehalfOf: testDelegator, Resource: "/protected/upload/12"}
+	got, err := r.authorize(context.Background(), claims)
+	require.NoError(t, err)
+	require.Equal(t, testDelegator, got.OnBehalfOf)
+
+	claims.OnBehalfOf = ""
+	_, err = r.authorize(context.Background(), claims)
+	require.Equal(t, ErrAccessDenied, err)
+}
@@ -0,0 +1,165 @@
+package didcomauth
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// DIDDocument is a W3C DID document, reduced to the parts needed to authenticate its subject.
+type DIDDocument struct {
+	ID                 string                     `json:"id"`
+	PublicKeys         []VerificationMethod       `json:"publicKey,omitempty"`
+	VerificationMethod []VerificationMethod       `json:"verificationMethod,omitempty"`
+	Authentication     []VerificationRelationship `json:"authentication,omitempty"`
+}
+
+// VerificationMethod is a public key published in a DID document.
+// Key material can be expressed as PEM, base58, multibase or JWK.
+type VerificationMethod struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Controller         string `json:"controller,omitempty"`
+	PublicKeyPem       string `json:"publicKeyPem,omitempty"`
+	PublicKeyBase58    string `json:"publicKeyBase58,omitempty"`
+	PublicKeyMultibase string `json:"publicKeyMultibase,omitempty"`
+	PublicKeyJwk       *JWK   `json:"publicKeyJwk,omitempty"`
+}
+
+// PublicKey returns the public key held by vm.
+func (vm VerificationMethod) PublicKey() (crypto.PublicKey, error) {
+	switch {
+	case vm.PublicKeyJwk != nil:
+		key, err := vm.PublicKeyJwk.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkKeyType(vm.Type, key); err != nil {
+			return nil, err
+		}
+
+		return key, nil
+	case vm.PublicKeyMultibase != "":
+		// Ed25519VerificationKey2020 and Multikey values carry a multicodec prefix
+		if vm.Type == KeyTypeEd255192020 || vm.Type == KeyTypeMultikey {
+			key, err := parseMulticodecKey(vm.PublicKeyMultibase)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := checkKeyType(vm.Type, key); err != nil {
+				return nil, err
+			}
+
+			return key, nil
+		}
+
+		raw, err := decodeMultibase(vm.PublicKeyMultibase)
+		if err != nil {
+			return nil, err
+		}
+
+		return parseRawPublicKey(vm.Type, raw)
+	case vm.PublicKeyBase58 != "":
+		raw := base58.Decode(vm.PublicKeyBase58)
+		if len(raw) == 0 {
+			return nil, errors.New("invalid base58 public key")
+		}
+
+		return parseRawPublicKey(vm.Type, raw)
+	default:
+		return parsePublicKey(vm.Type, vm.PublicKeyPem)
+	}
+}
+
+// VerificationRelationship is an entry of a DID document verification relationship, like authentication.
+// Entries either reference a verification method by ID, or embed one.
+type VerificationRelationship struct {
+	Reference string
+	Embedded  *VerificationMethod
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (vr *VerificationRelationship) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*vr = VerificationRelationship{}
+		return json.Unmarshal(data, &vr.Reference)
+	}
+
+	var vm VerificationMethod
+	if err := json.Unmarshal(data, &vm); err != nil {
+		return err
+	}
+
+	if vm.ID == "" {
+		return errors.New("embedded verification method without id")
+	}
+
+	*vr = VerificationRelationship{Embedded: &vm}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (vr VerificationRelationship) MarshalJSON() ([]byte, error) {
+	if vr.Embedded != nil {
+		return json.Marshal(vr.Embedded)
+	}
+
+	return json.Marshal(vr.Reference)
+}
+
+// Methods returns all the verification methods published in d, regardless of the property they're listed in.
+func (d DIDDocument) Methods() []VerificationMethod {
+	methods := make([]VerificationMethod, 0, len(d.PublicKeys)+len(d.VerificationMethod))
+	methods = append(methods, d.PublicKeys...)
+	return append(methods, d.VerificationMethod...)
+}
+
+// Method returns the verification method of d identified by id, which can also be relative to d, like "#keys-1".
+func (d DIDDocument) Method(id string) (VerificationMethod, bool) {
+	id = d.absoluteID(id)
+	for _, m := range d.Methods() {
+		if d.absoluteID(m.ID) == id {
+			return m, true
+		}
+	}
+
+	for _, a := range d.Authentication {
+		if a.Embedded != nil && d.absoluteID(a.Embedded.ID) == id {
+			return *a.Embedded, true
+		}
+	}
+
+	return VerificationMethod{}, false
+}
+
+// AuthenticationMethods returns the verification methods d lists in its authentication relationship, in order.
+// References to methods that aren't published in d are skipped.
+func (d DIDDocument) AuthenticationMethods() []VerificationMethod {
+	var methods []VerificationMethod
+	for _, a := range d.Authentication {
+		if a.Embedded != nil {
+			methods = append(methods, *a.Embedded)
+			continue
+		}
+
+		if m, ok := d.Method(a.Reference); ok {
+			methods = append(methods, m)
+		}
+	}
+
+	return methods
+}
+
+// absoluteID returns id prefixed with the document ID if it's a relative DID URL.
+func (d DIDDocument) absoluteID(id string) string {
+	if strings.HasPrefix(id, "#") {
+		return d.ID + id
+	}
+
+	return id
+}
@@ -0,0 +1,234 @@
+package didcomauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+)
+
+const testW3CDocument = `{
+	"id": "did:example:123",
+	"publicKey": [
+		{"id": "did:example:123#keys-1", "type": "RsaVerificationKey2018", "publicKeyPem": "pem-1"}
+	],
+	"verificationMethod": [
+		{"id": "#keys-2", "type": "Ed25519VerificationKey2018", "publicKeyPem": "pem-2"}
+	],
+	"authentication": [
+		"did:example:123#keys-2",
+		"#missing",
+		{"id": "did:example:123#keys-3", "type": "Ed25519VerificationKey2018", "publicKeyPem": "pem-3"}
+	]
+}`
+
+func TestDIDDocument_UnmarshalJSON(t *testing.T) {
+	var doc DIDDocument
+	require.NoError(t, json.Unmarshal([]byte(testW3CDocument), &doc))
+
+	require.Equal(t, "did:example:123", doc.ID)
+	require.Len(t, doc.Methods(), 2)
+	require.Len(t, doc.Authentication, 3)
+	require.Equal(t, "did:example:123#keys-2", doc.Authentication[0].Reference)
+	require.Nil(t, doc.Authentication[0].Embedded)
+	require.NotNil(t, doc.Authentication[2].Embedded)
+	require.Equal(t, "pem-3", doc.Authentication[2].Embedded.PublicKeyPem)
+
+	b, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	var again DIDDocument
+	require.NoError(t, json.Unmarshal(b, &again))
+	require.Equal(t, doc, again)
+}
+
+func TestVerificationRelationship_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    VerificationRelationship
+		wantErr bool
+	}{
+		{
+			"reference",
+			`"#keys-1"`,
+			VerificationRelationship{Reference: "#keys-1"},
+			false,
+		},
+		{
+			"embedded method",
+			`{"id": "#keys-1", "type": "t"}`,
+			VerificationRelationship{Embedded: &VerificationMethod{ID: "#keys-1", Type: "t"}},
+			false,
+		},
+		{
+			"embedded method without id",
+			`{"type": "t"}`,
+			VerificationRelationship{},
+			true,
+		},
+		{
+			"neither a string nor an object",
+			`42`,
+			VerificationRelationship{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var vr VerificationRelationship
+			err := json.Unmarshal([]byte(tt.data), &vr)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, vr)
+		})
+	}
+}
+
+func TestDIDDocument_Method(t *testing.T) {
+	var doc DIDDocument
+	require.NoError(t, json.Unmarshal([]byte(testW3CDocument), &doc))
+
+	tests := []struct {
+		name    string
+		id      string
+		wantPem string
+		found   bool
+	}{
+		{"absolute id", "did:example:123#keys-1", "pem-1", true},
+		{"relative id", "#keys-1", "pem-1", true},
+		{"relative id in document", "did:example:123#keys-2", "pem-2", true},
+		{"embedded in authentication", "#keys-3", "pem-3", true},
+		{"missing", "#keys-4", "", false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m, found := doc.Method(tt.id)
+			require.Equal(t, tt.found, found)
+			require.Equal(t, tt.wantPem, m.PublicKeyPem)
+		})
+	}
+}
+
+func TestDIDDocument_AuthenticationMethods(t *testing.T) {
+	var doc DIDDocument
+	require.NoError(t, json.Unmarshal([]byte(testW3CDocument), &doc))
+
+	methods := doc.AuthenticationMethods()
+	require.Len(t, methods, 2)
+	require.Equal(t, "pem-2", methods[0].PublicKeyPem)
+	require.Equal(t, "pem-3", methods[1].PublicKeyPem)
+}
+
+func TestVerificationMethod_PublicKey(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	s256Key, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		vm      VerificationMethod
+		wantErr bool
+	}{
+		{
+			"pem",
+			VerificationMethod{Type: KeyTypeEd25519, PublicKeyPem: testKeyPEM(t, edKey)},
+			false,
+		},
+		{
+			"base58 ed25519",
+			VerificationMethod{Type: KeyTypeEd25519, PublicKeyBase58: base58.Encode(edKey)},
+			false,
+		},
+		{
+			"base58 secp256k1",
+			VerificationMethod{
+				Type:            KeyTypeEcdsaSecp256k12019,
+				PublicKeyBase58: base58.Encode(s256Key.PubKey().SerializeCompressed()),
+			},
+			false,
+		},
+		{
+			"invalid base58",
+			VerificationMethod{Type: KeyTypeEd25519, PublicKeyBase58: "0OIl"},
+			true,
+		},
+		{
+			"multibase ed25519",
+			VerificationMethod{Type: KeyTypeEd25519, PublicKeyMultibase: "z" + base58.Encode(edKey)},
+			false,
+		},
+		{
+			"multicodec ed25519",
+			VerificationMethod{
+				Type:               KeyTypeEd255192020,
+				PublicKeyMultibase: "z" + base58.Encode(append([]byte{0xed, 0x01}, edKey...)),
+			},
+			false,
+		},
+		{
+			"multikey secp256k1",
+			VerificationMethod{
+				Type:               KeyTypeMultikey,
+				PublicKeyMultibase: "z" + base58.Encode(append([]byte{0xe7, 0x01}, s256Key.PubKey().SerializeCompressed()...)),
+			},
+			false,
+		},
+		{
+			"multicodec not matching type",
+			VerificationMethod{
+				Type:               KeyTypeEd255192020,
+				PublicKeyMultibase: "z" + base58.Encode(append([]byte{0xe7, 0x01}, s256Key.PubKey().SerializeCompressed()...)),
+			},
+			true,
+		},
+		{
+			"multibase not base58btc",
+			VerificationMethod{Type: KeyTypeEd25519, PublicKeyMultibase: "f" + hex.EncodeToString(edKey)},
+			true,
+		},
+		{
+			"jwk",
+			VerificationMethod{
+				Type:         "JsonWebKey2020",
+				PublicKeyJwk: &JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(edKey)},
+			},
+			false,
+		},
+		{
+			"jwk not matching type",
+			VerificationMethod{
+				Type:         KeyTypeRsaSignature,
+				PublicKeyJwk: &JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(edKey)},
+			},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.vm.PublicKey()
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, key)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, key)
+		})
+	}
+}
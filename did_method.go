@@ -0,0 +1,96 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// DID methods didcomauth can resolve on its own.
+const (
+	MethodCom = "com"
+	MethodKey = "key"
+	MethodWeb = "web"
+)
+
+// defaultMethods are the DID methods allowed when Config.AllowedMethods is empty.
+var defaultMethods = []string{MethodCom}
+
+// didMethod splits did in its method and method-specific identifier.
+func didMethod(did string) (string, string, error) {
+	parts := strings.SplitN(did, ":", 3)
+	if len(parts) != 3 || parts[0] != "did" || parts[1] == "" || parts[2] == "" {
+		return "", "", errors.New("invalid DID, expected did:<method>:<identifier>")
+	}
+
+	for _, r := range parts[1] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return "", "", fmt.Errorf("invalid DID method %s", parts[1])
+		}
+	}
+
+	return parts[1], parts[2], nil
+}
+
+// checkDID checks that did is well-formed and that its method is one of methods.
+// DIDs of methods didcomauth resolves on its own are checked more thoroughly, the others must only be syntactically
+// valid.
+func checkDID(did string, methods []string) error {
+	method, id, err := didMethod(did)
+	if err != nil {
+		return err
+	}
+
+	if !containsString(methods, method) {
+		return fmt.Errorf("invalid DID, method %s not allowed", method)
+	}
+
+	switch method {
+	case MethodCom:
+		if _, err := types.AccAddressFromBech32(did); err != nil {
+			return fmt.Errorf("invalid DID, %w", err)
+		}
+	case MethodKey:
+		if _, err := parseMulticodecKey(id); err != nil {
+			return fmt.Errorf("invalid DID, %w", err)
+		}
+	case MethodWeb:
+		if _, err := webDocumentURL(id); err != nil {
+			return fmt.Errorf("invalid DID, %w", err)
+		}
+	}
+
+	return nil
+}
+
+// MethodResolver is a DIDResolver dispatching each DID to the resolver of its method.
+type MethodResolver map[string]DIDResolver
+
+// Resolve implements the DIDResolver interface.
+func (m MethodResolver) Resolve(ctx context.Context, did string) (DIDDocument, error) {
+	method, _, err := didMethod(did)
+	if err != nil {
+		return DIDDocument{}, err
+	}
+
+	resolver, ok := m[method]
+	if !ok {
+		return DIDDocument{}, fmt.Errorf("no resolver for DID method %s", method)
+	}
+
+	return resolver.Resolve(ctx, did)
+}
+
+// containsString returns true if s is in list.
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_didMethod(t *testing.T) {
+	tests := []struct {
+		name       string
+		did        string
+		wantMethod string
+		wantID     string
+		wantErr    bool
+	}{
+		{"did:com", "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc", MethodCom, "15jv74vsdk23pvvf2a8arex339505mgjytz98xc", false},
+		{"did:web with path", "did:web:example.com:user:alice", MethodWeb, "example.com:user:alice", false},
+		{"not a did", "wrong", "", "", true},
+		{"no identifier", "did:key:", "", "", true},
+		{"no method", "did::abc", "", "", true},
+		{"uppercase method", "did:KEY:abc", "", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			method, id, err := didMethod(tt.did)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantMethod, method)
+			require.Equal(t, tt.wantID, id)
+		})
+	}
+}
+
+func TestMethodResolver_Resolve(t *testing.T) {
+	errCom := errors.New("com resolver")
+	mr := MethodResolver{
+		MethodCom: DIDResolverFunc(func(ctx context.Context, did string) (DIDDocument, error) {
+			return DIDDocument{}, errCom
+		}),
+		MethodKey: NewKeyResolver(),
+	}
+
+	_, err := mr.Resolve(context.Background(), "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc")
+	require.Equal(t, errCom, err)
+
+	doc, err := mr.Resolve(context.Background(), testDIDKeyEd25519)
+	require.NoError(t, err)
+	require.Equal(t, testDIDKeyEd25519, doc.ID)
+
+	_, err = mr.Resolve(context.Background(), "did:web:example.com")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no resolver for DID method web")
+
+	_, err = mr.Resolve(context.Background(), "wrong")
+	require.Error(t, err)
+}
@@ -1,8 +1,10 @@
 package didcomauth
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/mux"
 )
@@ -11,13 +13,40 @@ import (
 type router struct {
 	config Config
 	mr     *mux.Router
-	cp     cache
+	cp     ChallengeStore
+
+	mappingsOnce sync.Once
+	mappings     *mux.Router
+
+	keysOnce sync.Once
+	keys     *keyRing
+
+	patternsOnce sync.Once
+	patterns     map[string]resourcePattern
 }
 
 // instance is a package instance of the router, instantiated by Configure.
 // Subsequent calls to Configure will overwrite this instance.
 var instance *router
 
+// pinger is implemented by the ChallengeStores which can check that their backend is reachable, like the redis one.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// pingStore pings s, if it supports it.
+func pingStore(s ChallengeStore) error {
+	p, ok := s.(pinger)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisPingTimeout)
+	defer cancel()
+
+	return p.Ping(ctx)
+}
+
 // Configure configures DID:COM authentication endpoints on mr.
 func Configure(c Config, r *mux.Router) error {
 	if r == nil {
@@ -27,18 +56,33 @@ func Configure(c Config, r *mux.Router) error {
 	if err := c.Validate(); err != nil {
 		return err
 	}
+
+	if err := pingStore(c.ChallengeStore); err != nil {
+		return err
+	}
 	instance = &router{
-		c,
-		r,
-		c.CacheProvider,
+		config: c,
+		mr:     r,
+		cp:     c.ChallengeStore,
 	}
 
 	setCosmosConfig()
 
+	r.HandleFunc(jwksPath, instance.jwksHandler).Methods(http.MethodGet)
+
+	// logging out only needs the token, hence it's not under the auth subrouter which requires X-DID and X-Resource
+	r.HandleFunc(defaultAuthPath+defaultLogoutPath, instance.logoutPOSTHandler).Methods(http.MethodPost)
+
+	// introspecting services authenticate with their client credentials, and don't send X-DID and X-Resource either
+	if len(c.IntrospectionClients) > 0 {
+		r.HandleFunc(defaultAuthPath+defaultIntrospectionPath, instance.introspectionPOSTHandler).Methods(http.MethodPost)
+	}
+
 	authSubrouter := r.PathPrefix(defaultAuthPath).Subrouter()
-	authSubrouter.Use(neededHeadersMiddleware)
+	authSubrouter.Use(neededHeadersMiddleware(c.AllowedMethods))
 	authSubrouter.HandleFunc(defaultChallengePath, instance.challengeGETHandler).Methods(http.MethodGet)
 	authSubrouter.HandleFunc(defaultChallengePath, instance.challengePOSTHandler).Methods(http.MethodPost)
+	authSubrouter.HandleFunc(defaultRefreshPath, instance.refreshPOSTHandler).Methods(http.MethodPost)
 
 	protectedPaths := r.PathPrefix(c.ProtectedBasePath).Subrouter()
 	protectedPaths.Use(instance.checkAuthMiddleware)
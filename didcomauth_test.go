@@ -2,6 +2,7 @@ package didcomauth
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -58,3 +59,47 @@ func TestConfigure(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigure_jwks(t *testing.T) {
+	key := testJWTKeys(t)[2]
+	m := mux.NewRouter()
+
+	require.NoError(t, Configure(Config{
+		JWTSigningKey: &key,
+		ProtectedPaths: []ProtectedMapping{
+			{
+				Methods: []string{http.MethodGet},
+				Path:    "/get",
+				Handler: nil,
+			},
+		},
+		CacheType: CacheTypeMemory,
+	}, m))
+
+	rr := httptest.NewRecorder()
+	m.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), `"kid":"es256"`)
+}
+
+func TestConfigure_logout(t *testing.T) {
+	m := mux.NewRouter()
+
+	require.NoError(t, Configure(Config{
+		JWTSecret: "secret",
+		ProtectedPaths: []ProtectedMapping{
+			{
+				Methods: []string{http.MethodGet},
+				Path:    "/get",
+				Handler: nil,
+			},
+		},
+		CacheType: CacheTypeMemory,
+	}, m))
+
+	// logging out doesn't need the X-DID and X-Resource headers
+	rr := httptest.NewRecorder()
+	m.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/auth/logout", nil))
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Contains(t, rr.Body.String(), "not authorized")
+}
@@ -1,14 +1,9 @@
 package didcomauth
 
 import (
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/json"
-	"encoding/pem"
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
-	"strings"
 
 	idKeeper "github.com/commercionetwork/commercionetwork/x/id/keeper"
 )
@@ -21,66 +16,52 @@ type ddoResolveResponse struct {
 	Result idKeeper.ResolveIdentityResponse `json:"result"`
 }
 
-func (drr ddoResolveResponse) SigningPubKey() (*rsa.PublicKey, error) {
-	rawKeyStr := ""
-	for _, k := range drr.Result.DidDocument.PubKeys {
-		if strings.HasSuffix(k.ID, "#keys-2") {
-			rawKeyStr = k.PublicKeyPem
-		}
-	}
-
-	if rawKeyStr == "" {
-		return nil, errors.New("DDO doesn't have a verification key")
-	}
+// Document returns the DID document held by drr.
+func (drr ddoResolveResponse) Document() DIDDocument {
+	dd := drr.Result.DidDocument
 
-	block, _ := pem.Decode([]byte(rawKeyStr))
-
-	rawKey, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return nil, err
+	doc := DIDDocument{
+		ID:         dd.ID.String(),
+		PublicKeys: make([]VerificationMethod, 0, len(dd.PubKeys)),
 	}
 
-	key, ok := rawKey.(*rsa.PublicKey)
-	if !ok {
-		return nil, errors.New("selected key is not an rsa public key")
+	for _, k := range dd.PubKeys {
+		doc.PublicKeys = append(doc.PublicKeys, VerificationMethod{
+			ID:           k.ID,
+			Type:         k.Type,
+			Controller:   k.Controller.String(),
+			PublicKeyPem: k.PublicKeyPem,
+		})
 	}
 
-	return key, nil
+	return doc
 }
 
 func ddoURL(lcd string, did string) string {
 	return fmt.Sprintf(comDDOResolutionPath, lcd, did)
 }
 
-// resolveDDO resolves the DDO for did by querying lcd.
-func resolveDDO(lcd string, did string) (ddoResolveResponse, error) {
-	u := ddoURL(lcd, did)
-
-	data, err := http.Get(u)
-	if err != nil {
-		return ddoResolveResponse{}, fmt.Errorf("could not resolve DDO, %w", err)
-	}
-
-	if data.StatusCode == http.StatusNotFound {
-		return ddoResolveResponse{}, fmt.Errorf("ddo for %s not found", did)
-	}
+// lcdResolver resolves commercio.network DIDs through the legacy LCD REST endpoint.
+type lcdResolver struct {
+	pool *endpointPool
+}
 
-	if data.StatusCode != http.StatusOK {
-		return ddoResolveResponse{}, fmt.Errorf("LCD node responded with status %d", data.StatusCode)
-	}
+// NewLCDResolver returns a DIDResolver which queries the commercio.network LCD REST servers listed in opts.
+func NewLCDResolver(opts HTTPOptions) DIDResolver {
+	return lcdResolver{newEndpointPool(opts)}
+}
 
+// Resolve implements the DIDResolver interface.
+func (l lcdResolver) Resolve(ctx context.Context, did string) (DIDDocument, error) {
 	var drr ddoResolveResponse
-	jdec := json.NewDecoder(data.Body)
-	err = jdec.Decode(&drr)
-	if err != nil {
-		return ddoResolveResponse{}, fmt.Errorf("could not unmarshal DDO, %w", err)
+	urlFor := func(lcd string) string { return ddoURL(lcd, did) }
+	if err := l.pool.getJSON(ctx, urlFor, "", did, &drr); err != nil {
+		return DIDDocument{}, err
 	}
 
 	if drr.Result.DidDocument == nil {
-		return ddoResolveResponse{}, errors.New("ddo resolution okay but document is empty")
+		return DIDDocument{}, errors.New("ddo resolution okay but document is empty")
 	}
 
-	_ = data.Body.Close()
-
-	return drr, nil
+	return drr.Document(), nil
 }
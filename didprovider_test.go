@@ -1,6 +1,9 @@
 package didcomauth
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"net/http"
@@ -68,7 +71,7 @@ SQIDAQAB
 	}
 }
 
-func Test_ddoResolveResponse_SigningPubKey(t *testing.T) {
+func Test_ddoResolveResponse_Document(t *testing.T) {
 
 	okayDDO := testDidDocument()
 
@@ -80,6 +83,16 @@ func Test_ddoResolveResponse_SigningPubKey(t *testing.T) {
 		PublicKeyPem: "",
 	}
 
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	edDDO := testDidDocument()
+	edDDO.PubKeys[1].Type = KeyTypeEd25519
+	edDDO.PubKeys[1].PublicKeyPem = testKeyPEM(t, edKey)
+
+	mismatchDDO := testDidDocument()
+	mismatchDDO.PubKeys[1].Type = KeyTypeEd25519
+
 	tests := []struct {
 		name    string
 		drr     ddoResolveResponse
@@ -106,11 +119,28 @@ func Test_ddoResolveResponse_SigningPubKey(t *testing.T) {
 			}},
 			true,
 		},
+		{
+			"diddocument with an ed25519 signing key",
+			ddoResolveResponse{Result: idKeeper.ResolveIdentityResponse{
+				DidDocument: &edDDO,
+			}},
+			false,
+		},
+		{
+			"diddocument whose signing key doesn't match its type",
+			ddoResolveResponse{Result: idKeeper.ResolveIdentityResponse{
+				DidDocument: &mismatchDDO,
+			}},
+			true,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			k, e := tt.drr.SigningPubKey()
+			doc := tt.drr.Document()
+			require.Len(t, doc.PublicKeys, len(tt.drr.Result.DidDocument.PubKeys))
+
+			k, e := signingKey(AuthenticationKeySelector{}, doc, "")
 
 			if tt.wantErr {
 				require.Nil(t, k)
@@ -147,7 +177,7 @@ func Test_ddoURL(t *testing.T) {
 	}
 }
 
-func Test_resolveDDO(t *testing.T) {
+func Test_lcdResolver_Resolve(t *testing.T) {
 	lcd := "lcd"
 	did := "did"
 
@@ -205,16 +235,17 @@ func Test_resolveDDO(t *testing.T) {
 
 			httpmock.RegisterResponder(http.MethodGet, mockUrl, tt.responder)
 
-			ddo, err := resolveDDO(lcd, did)
+			ddo, err := NewLCDResolver(HTTPOptions{Endpoints: []string{lcd}, Retries: -1}).Resolve(context.Background(), did)
 
 			if tt.wantErr {
 				require.Error(t, err)
-				require.Nil(t, ddo.Result.DidDocument)
+				require.Empty(t, ddo.ID)
 				return
 			}
 
 			require.NoError(t, err)
-			require.NotNil(t, ddo.Result.DidDocument)
+			require.Equal(t, okayDidDocument.ID.String(), ddo.ID)
+			require.Len(t, ddo.PublicKeys, 2)
 		})
 	}
 }
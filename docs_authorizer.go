@@ -0,0 +1,185 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	comReceivedDocumentsPath = "%s/docs/%s/received"
+)
+
+// SharedDocument is a document shared through the commercio.network docs module, as returned by the LCD.
+type SharedDocument struct {
+	Sender     string                 `json:"sender"`
+	Recipients []string               `json:"recipients"`
+	UUID       string                 `json:"uuid"`
+	Metadata   SharedDocumentMetadata `json:"metadata"`
+	ContentURI string                 `json:"content_uri"`
+}
+
+// SharedDocumentMetadata is the metadata of a SharedDocument.
+type SharedDocumentMetadata struct {
+	ContentURI string `json:"content_uri"`
+	SchemaType string `json:"schema_type"`
+}
+
+// defaultDocsMethods are the methods shared documents give access with by default, read-only ones.
+var defaultDocsMethods = []string{http.MethodGet, http.MethodHead}
+
+// DocumentMatcher returns true if doc gives access to resource.
+type DocumentMatcher func(resource string, doc SharedDocument) bool
+
+// MatchDocument returns the default DocumentMatcher for the resources under prefix, such as /protected/documents/: a
+// document gives access to prefix followed by its UUID, as in /protected/documents/{uuid}, and to its metadata content
+// URI if it's under prefix. Resources outside prefix are never matched.
+func MatchDocument(prefix string) DocumentMatcher {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return func(resource string, doc SharedDocument) bool {
+		if !strings.HasPrefix(resource, prefix) {
+			return false
+		}
+
+		if doc.UUID != "" && strings.TrimPrefix(resource, prefix) == doc.UUID {
+			return true
+		}
+
+		return doc.Metadata.ContentURI != "" && doc.Metadata.ContentURI == resource
+	}
+}
+
+// DocsAuthorizerOptions configures the Authorizer returned by NewDocsAuthorizer.
+type DocsAuthorizerOptions struct {
+	// HTTPOptions lists the LCD nodes to query.
+	HTTPOptions
+
+	// Senders holds the DIDs whose documents give access to resources, usually the resource owners. It can't be
+	// empty, and documents DIDs send to themselves never give access.
+	Senders []string
+
+	// Prefix is the path the documents give access under, such as /protected/documents/, it can't be empty if Match
+	// is nil.
+	Prefix string
+
+	// Methods lists the methods the documents give access with, GET and HEAD if empty.
+	Methods []string
+
+	// Match tells which resources a document gives access to, MatchDocument(Prefix) if nil.
+	Match DocumentMatcher
+}
+
+// docsAuthorizer authorizes did:com DIDs to the resources of the documents they received through the
+// commercio.network docs module.
+type docsAuthorizer struct {
+	pool    *endpointPool
+	senders []string
+	methods []string
+	match   DocumentMatcher
+}
+
+// NewDocsAuthorizer returns an Authorizer which queries the commercio.network LCD REST servers listed in opts for the
+// documents the resource owners sent to the authenticated DID, or to the DID it acts on behalf of, and only grants the
+// resources one of them gives access to, with read methods only by default. DIDs of other methods than did:com are
+// denied.
+func NewDocsAuthorizer(opts DocsAuthorizerOptions) (Authorizer, error) {
+	if len(opts.Senders) == 0 {
+		return nil, errors.New("docs authorizer needs the DIDs of the document senders")
+	}
+
+	match := opts.Match
+	if match == nil {
+		if !strings.HasPrefix(opts.Prefix, "/") {
+			return nil, errors.New("docs authorizer needs the path prefix documents give access under")
+		}
+
+		match = MatchDocument(opts.Prefix)
+	}
+
+	methods := opts.Methods
+	if len(methods) == 0 {
+		methods = defaultDocsMethods
+	}
+
+	return docsAuthorizer{
+		pool:    newEndpointPool(opts.HTTPOptions),
+		senders: opts.Senders,
+		methods: methods,
+		match:   match,
+	}, nil
+}
+
+func receivedDocumentsURL(lcd, did string) string {
+	return fmt.Sprintf(comReceivedDocumentsPath, lcd, did)
+}
+
+// receivedDocuments returns the documents the allowed senders, other than did itself, sent to did.
+func (d docsAuthorizer) receivedDocuments(ctx context.Context, did string) ([]SharedDocument, error) {
+	var resp struct {
+		Result []SharedDocument `json:"result"`
+	}
+
+	urlFor := func(lcd string) string { return receivedDocumentsURL(lcd, did) }
+	if err := d.pool.getJSON(ctx, urlFor, "", did, &resp); err != nil {
+		// the LCD answers 404 to DIDs it knows nothing about
+		if errors.Is(err, ErrDIDNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not query shared documents, %w", err)
+	}
+
+	var docs []SharedDocument
+	for _, doc := range resp.Result {
+		if doc.Sender != did && containsString(d.senders, doc.Sender) {
+			docs = append(docs, doc)
+		}
+	}
+
+	return docs, nil
+}
+
+// Authorize implements the Authorizer interface.
+func (d docsAuthorizer) Authorize(ctx context.Context, req AuthorizationRequest) (Authorization, error) {
+	did := req.principal()
+	if method, _, err := didMethod(did); err != nil || method != MethodCom {
+		return Authorization{}, ErrAccessDenied
+	}
+
+	docs, err := d.receivedDocuments(ctx, did)
+	if err != nil {
+		return Authorization{}, err
+	}
+
+	var grants []Grant
+	for _, g := range req.Grants {
+		var methods []string
+		for _, m := range g.Methods {
+			if containsString(d.methods, m) {
+				methods = append(methods, m)
+			}
+		}
+
+		if len(methods) == 0 {
+			continue
+		}
+
+		for _, doc := range docs {
+			if d.match(g.Resource, doc) {
+				grants = append(grants, Grant{Resource: g.Resource, Methods: methods})
+				break
+			}
+		}
+	}
+
+	if len(grants) == 0 {
+		return Authorization{}, ErrAccessDenied
+	}
+
+	return Authorization{Grants: grants}, nil
+}
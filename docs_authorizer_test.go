@@ -0,0 +1,184 @@
+package didcomauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+// mockLCD returns a local LCD serving the documents received by DIDs, answering 500 to failing DIDs.
+func mockLCD(t *testing.T, received map[string]string, failing string) *httptest.Server {
+	m := mux.NewRouter()
+	m.HandleFunc("/docs/{user}/received", func(w http.ResponseWriter, r *http.Request) {
+		user := mux.Vars(r)["user"]
+		if user == failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		docs, ok := received[user]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"height":"12","result":` + docs + `}`))
+	}).Methods(http.MethodGet)
+
+	srv := httptest.NewServer(m)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func Test_receivedDocumentsURL(t *testing.T) {
+	require.Equal(t, "http://lcd/docs/did:com:1/received", receivedDocumentsURL("http://lcd", "did:com:1"))
+}
+
+func TestMatchDocument(t *testing.T) {
+	doc := SharedDocument{
+		UUID:     "6a2f41a3-c54c-fce8-32d2-0324e1c32e22",
+		Metadata: SharedDocumentMetadata{ContentURI: "/protected/documents/reports/2020"},
+	}
+
+	match := MatchDocument("/protected/documents")
+	require.True(t, match("/protected/documents/6a2f41a3-c54c-fce8-32d2-0324e1c32e22", doc))
+	require.True(t, match("/protected/documents/reports/2020", doc))
+	require.False(t, match("/protected/documents/12", doc))
+	require.False(t, match("/protected/documents/", SharedDocument{}))
+	require.False(t, match("/protected/admin/6a2f41a3-c54c-fce8-32d2-0324e1c32e22", doc), "resources outside the prefix")
+	require.False(t, match("/protected/documents/12/6a2f41a3-c54c-fce8-32d2-0324e1c32e22", doc))
+
+	doc.Metadata.ContentURI = "/protected/admin"
+	require.False(t, match("/protected/admin", doc), "content URIs outside the prefix")
+}
+
+func TestNewDocsAuthorizer(t *testing.T) {
+	owner := "did:com:12p24st9asf394jv04e8sxrl9c384jjqwejv0gf"
+	match := func(string, SharedDocument) bool { return true }
+
+	tests := []struct {
+		name    string
+		opts    DocsAuthorizerOptions
+		wantErr bool
+	}{
+		{"okay", DocsAuthorizerOptions{Senders: []string{owner}, Prefix: "/protected/documents/"}, false},
+		{"custom matcher", DocsAuthorizerOptions{Senders: []string{owner}, Match: match}, false},
+		{"no senders", DocsAuthorizerOptions{Prefix: "/protected/documents/"}, true},
+		{"no prefix", DocsAuthorizerOptions{Senders: []string{owner}}, true},
+		{"relative prefix", DocsAuthorizerOptions{Senders: []string{owner}, Prefix: "documents/"}, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDocsAuthorizer(tt.opts)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_docsAuthorizer_Authorize(t *testing.T) {
+	owner := "did:com:12p24st9asf394jv04e8sxrl9c384jjqwejv0gf"
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	stranger := "did:com:1stranger"
+	failing := "did:com:1failing"
+
+	lcd := mockLCD(t, map[string]string{
+		did: `[
+			{
+				"sender": "` + owner + `",
+				"recipients": ["` + did + `"],
+				"uuid": "6a2f41a3-c54c-fce8-32d2-0324e1c32e22",
+				"metadata": {"content_uri": "/protected/documents/reports/2020", "schema_type": "uni-sincro"},
+				"content_uri": "https://example.com/document"
+			},
+			{
+				"sender": "` + did + `",
+				"recipients": ["` + did + `"],
+				"uuid": "d83422c6-6e79-4a99-9767-fcae46dfa371",
+				"metadata": {"content_uri": "/protected/documents/reports/2021", "schema_type": "uni-sincro"}
+			},
+			{
+				"sender": "` + stranger + `",
+				"recipients": ["` + did + `"],
+				"uuid": "0b7c6a3e-55a1-4b7e-9c43-52e1f3c3e7a1",
+				"metadata": {"content_uri": "/protected/documents/reports/2022", "schema_type": "uni-sincro"}
+			}
+		]`,
+	}, failing)
+
+	get := []string{http.MethodGet}
+	document := Grant{Resource: "/protected/documents/6a2f41a3-c54c-fce8-32d2-0324e1c32e22", Methods: get}
+	report := Grant{Resource: "/protected/documents/reports/2020", Methods: get}
+	selfSent := Grant{Resource: "/protected/documents/reports/2021", Methods: get}
+	strangers := Grant{Resource: "/protected/documents/reports/2022", Methods: get}
+	other := Grant{Resource: "/protected/documents/12", Methods: get}
+	writable := Grant{Resource: document.Resource, Methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete}}
+
+	defaults := DocsAuthorizerOptions{Senders: []string{owner}, Prefix: "/protected/documents/"}
+	selfSender := DocsAuthorizerOptions{Senders: []string{owner, did}, Prefix: "/protected/documents/"}
+	putter := DocsAuthorizerOptions{Senders: []string{owner}, Prefix: "/protected/documents/", Methods: []string{http.MethodPut}}
+
+	tests := []struct {
+		name       string
+		opts       DocsAuthorizerOptions
+		did        string
+		grants     []Grant
+		wantGrants []Grant
+		wantErr    error
+	}{
+		{"by UUID", defaults, did, []Grant{document}, []Grant{document}, nil},
+		{"by metadata", defaults, did, []Grant{report}, []Grant{report}, nil},
+		{"no matching document", defaults, did, []Grant{other}, nil, ErrAccessDenied},
+		{"matching grants only", defaults, did, []Grant{other, report}, []Grant{report}, nil},
+		{"senders not allowed", defaults, did, []Grant{strangers}, nil, ErrAccessDenied},
+		{"sent to themselves", selfSender, did, []Grant{selfSent}, nil, ErrAccessDenied},
+		{"read methods only", defaults, did, []Grant{writable}, []Grant{document}, nil},
+		{"custom methods", putter, did, []Grant{writable}, []Grant{{Resource: document.Resource, Methods: []string{http.MethodPut}}}, nil},
+		{"no documents", defaults, owner, []Grant{document}, nil, ErrAccessDenied},
+		{"other method", defaults, "did:key:z6Mk", []Grant{document}, nil, ErrAccessDenied},
+		{
+			"custom matcher",
+			DocsAuthorizerOptions{Senders: []string{owner}, Match: func(resource string, doc SharedDocument) bool {
+				return doc.Metadata.SchemaType == "uni-sincro" && resource == "/protected/sincro"
+			}},
+			did,
+			[]Grant{document, {Resource: "/protected/sincro", Methods: get}},
+			[]Grant{{Resource: "/protected/sincro", Methods: get}},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Endpoints = []string{lcd.URL}
+			a, err := NewDocsAuthorizer(tt.opts)
+			require.NoError(t, err)
+
+			got, err := a.Authorize(context.Background(), AuthorizationRequest{
+				DID:      tt.did,
+				Resource: tt.grants[0].Resource,
+				Grants:   tt.grants,
+			})
+			require.Equal(t, tt.wantErr, err)
+			require.Equal(t, tt.wantGrants, got.Grants)
+		})
+	}
+
+	// LCD failures aren't denials
+	opts := defaults
+	opts.HTTPOptions = HTTPOptions{Endpoints: []string{lcd.URL}, Retries: -1}
+	a, err := NewDocsAuthorizer(opts)
+	require.NoError(t, err)
+	_, err = a.Authorize(context.Background(), AuthorizationRequest{DID: failing, Grants: []Grant{document}})
+	require.Error(t, err)
+	require.NotEqual(t, ErrAccessDenied, err)
+}
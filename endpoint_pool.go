@@ -0,0 +1,190 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHTTPTimeout      = 5 * time.Second
+	defaultHTTPRetries      = 2
+	defaultHTTPBackoff      = 100 * time.Millisecond
+	defaultFailureThreshold = 3
+	defaultCooldown         = 30 * time.Second
+)
+
+var errNoHealthyEndpoint = errors.New("could not resolve DDO, no healthy endpoint available")
+
+// HTTPOptions configures how HTTP-based DID resolvers reach the nodes they query.
+type HTTPOptions struct {
+	// Endpoints are the base URLs of the nodes to query, in order of preference.
+	Endpoints []string
+
+	// Client is the HTTP client requests are made with, http.DefaultClient if nil.
+	Client *http.Client
+
+	// Timeout bounds each request made to a node, 5 seconds if zero.
+	// Requests are also bound to the context of the HTTP request being authenticated.
+	Timeout time.Duration
+
+	// Retries is the number of times a failed request is retried on the next healthy node, 2 if zero.
+	// Negative values disable retries.
+	Retries int
+
+	// Backoff is the time waited before the first retry, doubled at each subsequent retry, 100ms if zero.
+	Backoff time.Duration
+
+	// FailureThreshold is the number of consecutive failures after which a node is skipped for Cooldown,
+	// 3 and 30 seconds if zero.
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// endpoint is a node an endpointPool queries, along with its circuit breaker state.
+type endpoint struct {
+	url       string
+	failures  int
+	openUntil time.Time
+}
+
+// endpointPool sends requests to the nodes listed in HTTPOptions, retrying failed requests on the next node and
+// skipping the nodes which keep failing.
+type endpointPool struct {
+	opts HTTPOptions
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+}
+
+// newEndpointPool returns an endpointPool for opts, with defaults applied.
+func newEndpointPool(opts HTTPOptions) *endpointPool {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultHTTPTimeout
+	}
+
+	switch {
+	case opts.Retries == 0:
+		opts.Retries = defaultHTTPRetries
+	case opts.Retries < 0:
+		opts.Retries = 0
+	}
+
+	if opts.Backoff == 0 {
+		opts.Backoff = defaultHTTPBackoff
+	}
+
+	if opts.FailureThreshold == 0 {
+		opts.FailureThreshold = defaultFailureThreshold
+	}
+
+	if opts.Cooldown == 0 {
+		opts.Cooldown = defaultCooldown
+	}
+
+	p := &endpointPool{opts: opts}
+	for _, u := range opts.Endpoints {
+		p.endpoints = append(p.endpoints, &endpoint{url: u})
+	}
+
+	return p
+}
+
+// getJSON GETs the URL urlFor builds for a node and decodes its JSON body in v.
+func (p *endpointPool) getJSON(ctx context.Context, urlFor func(endpoint string) string, accept, did string,
+	v interface{}) error {
+	if len(p.endpoints) == 0 {
+		return errors.New("could not resolve DDO, no endpoint configured")
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= p.opts.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(p.opts.Backoff << uint(attempt-1)):
+			case <-ctx.Done():
+				return lastErr
+			}
+		}
+
+		ep := p.pick(attempt)
+		if ep == nil {
+			if lastErr == nil {
+				lastErr = errNoHealthyEndpoint
+			}
+
+			return lastErr
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+		err := getJSON(reqCtx, p.opts.Client, urlFor(ep.url), accept, did, v)
+		cancel()
+
+		if err == nil || !retryable(err) {
+			p.report(ep, true)
+			return err
+		}
+
+		// the caller giving up is not the node's fault
+		if ctx.Err() != nil {
+			return err
+		}
+
+		p.report(ep, false)
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+// pick returns the first available node starting from the attempt-th one, or nil if all of them are skipped.
+func (p *endpointPool) pick(attempt int) *endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for i := range p.endpoints {
+		ep := p.endpoints[(attempt+i)%len(p.endpoints)]
+		if !now.Before(ep.openUntil) {
+			return ep
+		}
+	}
+
+	return nil
+}
+
+// report records the outcome of a request made to ep.
+func (p *endpointPool) report(ep *endpoint, healthy bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if healthy {
+		ep.failures = 0
+		ep.openUntil = time.Time{}
+		return
+	}
+
+	ep.failures++
+	if ep.failures >= p.opts.FailureThreshold {
+		ep.openUntil = time.Now().Add(p.opts.Cooldown)
+	}
+}
+
+// retryable returns true if err is a transport error or a server-side failure, which another node or a later
+// attempt might not incur.
+func retryable(err error) bool {
+	var se statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError || se.code == http.StatusTooManyRequests
+	}
+
+	var ue *url.Error
+	return errors.As(err, &ue)
+}
@@ -0,0 +1,186 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func testURLFor(endpoint string) string {
+	return endpoint + "/did"
+}
+
+func Test_endpointPool_getJSON(t *testing.T) {
+	okay := httpmock.NewStringResponder(http.StatusOK, `{"id": "did"}`)
+	down := httpmock.NewStringResponder(http.StatusServiceUnavailable, "")
+	notFound := httpmock.NewStringResponder(http.StatusNotFound, "")
+	broken := httpmock.NewErrorResponder(errors.New("connection refused"))
+
+	tests := []struct {
+		name       string
+		responders []httpmock.Responder
+		retries    int
+		wantErr    bool
+		wantCalls  []int
+	}{
+		{"first node answers", []httpmock.Responder{okay, okay}, 0, false, []int{1, 0}},
+		{"failover to second node", []httpmock.Responder{down, okay}, 0, false, []int{1, 1}},
+		{"transport errors are retried", []httpmock.Responder{broken, okay}, 0, false, []int{1, 1}},
+		{"retries go around the nodes", []httpmock.Responder{down, down}, 3, true, []int{2, 2}},
+		{"retries disabled", []httpmock.Responder{down, okay}, -1, true, []int{1, 0}},
+		{"not found is not retried", []httpmock.Responder{notFound, okay}, 0, true, []int{1, 0}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			opts := HTTPOptions{Retries: tt.retries, Backoff: time.Millisecond, FailureThreshold: 10}
+			for i, r := range tt.responders {
+				ep := fmt.Sprintf("http://node%d", i)
+				opts.Endpoints = append(opts.Endpoints, ep)
+				httpmock.RegisterResponder(http.MethodGet, testURLFor(ep), r)
+			}
+
+			var doc DIDDocument
+			err := newEndpointPool(opts).getJSON(context.Background(), testURLFor, "", "did", &doc)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "did", doc.ID)
+			}
+
+			calls := httpmock.GetCallCountInfo()
+			for i, want := range tt.wantCalls {
+				require.Equal(t, want, calls["GET "+testURLFor(fmt.Sprintf("http://node%d", i))])
+			}
+		})
+	}
+}
+
+func Test_endpointPool_circuitBreaker(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://bad/did", httpmock.NewStringResponder(http.StatusBadGateway, ""))
+	httpmock.RegisterResponder(http.MethodGet, "http://good/did", httpmock.NewStringResponder(http.StatusOK, "{}"))
+
+	p := newEndpointPool(HTTPOptions{
+		Endpoints:        []string{"http://bad", "http://good"},
+		Retries:          -1,
+		FailureThreshold: 2,
+		Cooldown:         50 * time.Millisecond,
+	})
+
+	var doc DIDDocument
+	for i := 0; i < 2; i++ {
+		require.Error(t, p.getJSON(context.Background(), testURLFor, "", "did", &doc))
+	}
+
+	// the bad node is now skipped
+	require.NoError(t, p.getJSON(context.Background(), testURLFor, "", "did", &doc))
+	require.Equal(t, 2, httpmock.GetCallCountInfo()["GET http://bad/did"])
+
+	// after the cooldown it's given another chance
+	time.Sleep(60 * time.Millisecond)
+	require.Error(t, p.getJSON(context.Background(), testURLFor, "", "did", &doc))
+	require.Equal(t, 3, httpmock.GetCallCountInfo()["GET http://bad/did"])
+}
+
+func Test_endpointPool_noHealthyEndpoint(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://bad/did", httpmock.NewStringResponder(http.StatusBadGateway, ""))
+
+	p := newEndpointPool(HTTPOptions{
+		Endpoints:        []string{"http://bad"},
+		Retries:          -1,
+		FailureThreshold: 1,
+	})
+
+	var doc DIDDocument
+	require.Error(t, p.getJSON(context.Background(), testURLFor, "", "did", &doc))
+	require.Equal(t, errNoHealthyEndpoint, p.getJSON(context.Background(), testURLFor, "", "did", &doc))
+
+	require.Error(t, newEndpointPool(HTTPOptions{}).getJSON(context.Background(), testURLFor, "", "did", &doc))
+}
+
+func Test_endpointPool_timeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": "did"}`))
+	}))
+	defer fast.Close()
+
+	tests := []struct {
+		name      string
+		endpoints []string
+		ctxExpiry time.Duration
+		wantErr   bool
+	}{
+		{"slow node times out, fast one answers", []string{slow.URL, fast.URL}, time.Second, false},
+		{"request context expires before any retry", []string{slow.URL, fast.URL}, 10 * time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.ctxExpiry)
+			defer cancel()
+
+			p := newEndpointPool(HTTPOptions{
+				Endpoints: tt.endpoints,
+				Timeout:   50 * time.Millisecond,
+				Backoff:   time.Millisecond,
+			})
+
+			var doc DIDDocument
+			err := p.getJSON(ctx, testURLFor, "", "did", &doc)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "did", doc.ID)
+		})
+	}
+}
+
+func Test_retryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", statusError{http.StatusInternalServerError}, true},
+		{"too many requests", statusError{http.StatusTooManyRequests}, true},
+		{"client error", statusError{http.StatusBadRequest}, false},
+		{"not found", fmt.Errorf("ddo for did not found, %w", ErrDIDNotFound), false},
+		{"transport error", fmt.Errorf("could not resolve DDO, %w", &url.Error{Err: errors.New("refused")}), true},
+		{"decoding error", errors.New("could not unmarshal DDO"), false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, retryable(tt.err))
+		})
+	}
+}
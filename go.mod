@@ -3,6 +3,8 @@ module github.com/commercionetwork/didcomauth
 go 1.14
 
 require (
+	github.com/btcsuite/btcd v0.20.1-beta
+	github.com/btcsuite/btcutil v0.0.0-20190425235716-9e5f4b9a998d
 	github.com/commercionetwork/commercionetwork v1.5.1-0.20200502084509-a6bd5d0b43d6
 	github.com/cosmos/cosmos-sdk v0.38.3
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
@@ -10,4 +12,6 @@ require (
 	github.com/gorilla/mux v1.7.4
 	github.com/jarcoal/httpmock v1.0.5
 	github.com/stretchr/testify v1.5.1
+	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,88 @@
+package didcomauth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxGrants is the maximum number of grants a token can be requested for.
+const maxGrants = 32
+
+// Grant gives access to a resource, a path or a resource pattern, with the listed HTTP methods.
+type Grant struct {
+	Resource string   `json:"resource"`
+	Methods  []string `json:"methods"`
+}
+
+// validGrantMethods holds the HTTP methods a grant can list.
+var validGrantMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// checkGrants returns an error if grants can't be requested: there must be at most maxGrants of them, each listing a
+// path or an allowed resource pattern and at least one HTTP method.
+func (r *router) checkGrants(grants []Grant) error {
+	if len(grants) > maxGrants {
+		return fmt.Errorf("at most %d grants can be requested", maxGrants)
+	}
+
+	for _, g := range grants {
+		if !strings.HasPrefix(g.Resource, "/") {
+			return fmt.Errorf("grant resource %q must begin with /", g.Resource)
+		}
+
+		if err := r.checkResource(g.Resource); err != nil {
+			return err
+		}
+
+		if len(g.Methods) == 0 {
+			return fmt.Errorf("grant for %s has no methods", g.Resource)
+		}
+
+		for _, m := range g.Methods {
+			if !containsString(validGrantMethods, m) {
+				return fmt.Errorf("grant for %s has invalid method %q", g.Resource, m)
+			}
+		}
+	}
+
+	return nil
+}
+
+// claimsAllow returns true if claims give access to path with method.
+// Tokens with grants give access to the paths and methods they list, tokens without only to their resource, with any
+// method.
+func (r *router) claimsAllow(claims *DidComAuthClaims, method, path string) bool {
+	if len(claims.Grants) == 0 {
+		return r.resourceMatches(claims.Resource, path)
+	}
+
+	for _, g := range claims.Grants {
+		if r.resourceMatches(g.Resource, path) && containsString(g.Methods, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// claimsResources returns the resources claims give access to.
+func claimsResources(claims DidComAuthClaims) []string {
+	if len(claims.Grants) == 0 {
+		return []string{claims.Resource}
+	}
+
+	resources := make([]string, 0, len(claims.Grants))
+	for _, g := range claims.Grants {
+		resources = append(resources, g.Resource)
+	}
+
+	return resources
+}
@@ -0,0 +1,127 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_router_checkGrants(t *testing.T) {
+	r := &router{config: Config{ResourcePatterns: []string{"/protected/upload/*"}}}
+
+	tooMany := make([]Grant, maxGrants+1)
+	for i := range tooMany {
+		tooMany[i] = Grant{Resource: "/protected/profile", Methods: []string{http.MethodGet}}
+	}
+
+	tests := []struct {
+		name    string
+		grants  []Grant
+		wantErr bool
+	}{
+		{"no grants", nil, false},
+		{"path and pattern", []Grant{
+			{Resource: "/protected/profile", Methods: []string{http.MethodGet, http.MethodPut}},
+			{Resource: "/protected/upload/*", Methods: []string{http.MethodGet}},
+		}, false},
+		{"too many grants", tooMany, true},
+		{"relative resource", []Grant{{Resource: "protected/profile", Methods: []string{http.MethodGet}}}, true},
+		{"pattern not allowed", []Grant{{Resource: "/protected/*", Methods: []string{http.MethodGet}}}, true},
+		{"no methods", []Grant{{Resource: "/protected/profile"}}, true},
+		{"invalid method", []Grant{{Resource: "/protected/profile", Methods: []string{"get"}}}, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.checkGrants(tt.grants)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_router_claimsAllow(t *testing.T) {
+	r := &router{config: Config{ResourcePatterns: []string{"/protected/upload/*"}}}
+
+	legacy := &DidComAuthClaims{Resource: "/protected/profile"}
+	require.True(t, r.claimsAllow(legacy, http.MethodGet, "/protected/profile"))
+	require.True(t, r.claimsAllow(legacy, http.MethodDelete, "/protected/profile"))
+	require.False(t, r.claimsAllow(legacy, http.MethodGet, "/protected/upload/12"))
+
+	granted := &DidComAuthClaims{
+		Resource: "/protected/profile",
+		Grants: []Grant{
+			{Resource: "/protected/profile", Methods: []string{http.MethodGet}},
+			{Resource: "/protected/upload/*", Methods: []string{http.MethodGet, http.MethodPut}},
+		},
+	}
+	require.True(t, r.claimsAllow(granted, http.MethodGet, "/protected/profile"))
+	require.False(t, r.claimsAllow(granted, http.MethodDelete, "/protected/profile"))
+	require.True(t, r.claimsAllow(granted, http.MethodPut, "/protected/upload/12"))
+	require.False(t, r.claimsAllow(granted, http.MethodPost, "/protected/upload/12"))
+	require.False(t, r.claimsAllow(granted, http.MethodGet, "/protected/other"))
+}
+
+func Test_checkAuth_grants(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{
+		config: Config{JWTSecret: "secret", RefreshTokenLifetime: time.Hour, ResourcePatterns: []string{"/protected/upload/*"}},
+		cp:     newCTest(false),
+	}
+
+	claims := DidComAuthClaims{
+		Resource: "/protected/profile",
+		DID:      did,
+		Grants: []Grant{
+			{Resource: "/protected/profile", Methods: []string{http.MethodGet}},
+			{Resource: "/protected/upload/*", Methods: []string{http.MethodPut}},
+		},
+	}
+	first, err := r.releaseTokens(context.Background(), claims, time.Time{})
+	require.NoError(t, err)
+
+	// refreshed tokens carry the same grants
+	body, err := json.Marshal(RefreshRequest{RefreshToken: first.RefreshToken})
+	require.NoError(t, err)
+	rr := refreshRequest(t, r, did, "/protected/profile", string(body))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var refreshed ReleaseJWTResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &refreshed))
+
+	protected := checkAuth{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) }),
+		r:    r,
+	}
+
+	for _, token := range []string{first.Token, refreshed.Token} {
+		tests := []struct {
+			method string
+			path   string
+			want   int
+		}{
+			{http.MethodGet, "/protected/profile", http.StatusOK},
+			{http.MethodPut, "/protected/profile", http.StatusForbidden},
+			{http.MethodPut, "/protected/upload/12", http.StatusOK},
+			{http.MethodGet, "/protected/upload/12", http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(authHeader, "Bearer "+token)
+			req.Header.Set(DIDHeader, did)
+			req.Header.Set(ResourceHeader, "/protected/profile")
+
+			rr := httptest.NewRecorder()
+			protected.ServeHTTP(rr, req)
+			require.Equal(t, tt.want, rr.Code, tt.method+" "+tt.path)
+		}
+	}
+}
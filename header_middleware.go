@@ -2,10 +2,9 @@ package didcomauth
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 
-	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/gorilla/mux"
 )
 
 const (
@@ -14,7 +13,8 @@ const (
 )
 
 type neededHeaders struct {
-	next http.Handler
+	next    http.Handler
+	methods []string
 }
 
 func (n neededHeaders) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -36,7 +36,12 @@ func (n neededHeaders) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := checkDID(did); err != nil {
+	methods := n.methods
+	if len(methods) == 0 {
+		methods = defaultMethods
+	}
+
+	if err := checkDID(did, methods); err != nil {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -44,16 +49,10 @@ func (n neededHeaders) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	n.next.ServeHTTP(w, r)
 }
 
-// neededHeadersMiddleware checks that X-DID and X-Resource headers are present and valid.
-func neededHeadersMiddleware(next http.Handler) http.Handler {
-	return neededHeaders{next}
-}
-
-// checkDID checks that did is a Commercio.network one.
-func checkDID(did string) error {
-	if _, err := types.AccAddressFromBech32(did); err != nil {
-		return fmt.Errorf("invalid DID, %w", err)
+// neededHeadersMiddleware checks that X-DID and X-Resource headers are present and valid, accepting DIDs of the
+// given methods.
+func neededHeadersMiddleware(methods []string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return neededHeaders{next, methods}
 	}
-
-	return nil
 }
@@ -11,31 +11,78 @@ import (
 func Test_checkDID(t *testing.T) {
 	setCosmosConfig()
 
+	allMethods := []string{MethodCom, MethodKey, MethodWeb, "example"}
+
 	tests := []struct {
 		name    string
 		did     string
+		methods []string
 		wantErr bool
 	}{
 		{
 			"a good did",
 			"did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc",
+			defaultMethods,
 			false,
 		},
 		{
 			"wrong did",
 			"wrong",
+			defaultMethods,
+			true,
+		},
+		{
+			"bad did:com",
+			"did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xd",
+			allMethods,
+			true,
+		},
+		{
+			"method not allowed",
+			testDIDKeyEd25519,
+			defaultMethods,
+			true,
+		},
+		{
+			"a good did:key",
+			testDIDKeyEd25519,
+			allMethods,
+			false,
+		},
+		{
+			"bad did:key",
+			"did:key:z6Mk",
+			allMethods,
 			true,
 		},
+		{
+			"a good did:web",
+			"did:web:example.com%3A8443:user:alice",
+			allMethods,
+			false,
+		},
+		{
+			"bad did:web",
+			"did:web:example.com::alice",
+			allMethods,
+			true,
+		},
+		{
+			"method resolved by a custom resolver",
+			"did:example:123456",
+			allMethods,
+			false,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.wantErr {
-				require.Error(t, checkDID(tt.did))
+				require.Error(t, checkDID(tt.did, tt.methods))
 				return
 			}
 
-			require.NoError(t, checkDID(tt.did))
+			require.NoError(t, checkDID(tt.did, tt.methods))
 		})
 	}
 }
@@ -46,6 +93,7 @@ func Test_neededHeaders_ServeHTTP(t *testing.T) {
 	tests := []struct {
 		name           string
 		headers        map[string]string
+		methods        []string
 		expectedStatus int
 		expectedData   string
 		nextHandler    http.HandlerFunc
@@ -53,6 +101,7 @@ func Test_neededHeaders_ServeHTTP(t *testing.T) {
 		{
 			"no required headers",
 			nil,
+			nil,
 			http.StatusBadRequest,
 			"X-DID header not defined", // the first thing we check is the DID, so we expect a did-related error first
 			nil,
@@ -62,6 +111,7 @@ func Test_neededHeaders_ServeHTTP(t *testing.T) {
 			map[string]string{
 				DIDHeader: "did",
 			},
+			nil,
 			http.StatusBadRequest,
 			"X-Resource header not defined",
 			nil,
@@ -72,16 +122,42 @@ func Test_neededHeaders_ServeHTTP(t *testing.T) {
 				DIDHeader:      "did",
 				ResourceHeader: "/resource",
 			},
+			nil,
 			http.StatusBadRequest,
 			"invalid DID",
 			nil,
 		},
+		{
+			"both headers defined, DID method not allowed",
+			map[string]string{
+				DIDHeader:      testDIDKeyEd25519,
+				ResourceHeader: "/resource",
+			},
+			nil,
+			http.StatusBadRequest,
+			"method key not allowed",
+			nil,
+		},
+		{
+			"both headers defined, allowed did:key DID",
+			map[string]string{
+				DIDHeader:      testDIDKeyEd25519,
+				ResourceHeader: "/resource",
+			},
+			[]string{MethodCom, MethodKey},
+			http.StatusOK,
+			"",
+			func(writer http.ResponseWriter, request *http.Request) {
+				writer.WriteHeader(http.StatusOK)
+			},
+		},
 		{
 			"both headers defined, okay did DID",
 			map[string]string{
 				DIDHeader:      "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc",
 				ResourceHeader: "/resource",
 			},
+			nil,
 			http.StatusOK,
 			"",
 			func(writer http.ResponseWriter, request *http.Request) {
@@ -93,7 +169,8 @@ func Test_neededHeaders_ServeHTTP(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			n := neededHeaders{
-				next: tt.nextHandler, // we just check that the middleware itself works
+				next:    tt.nextHandler, // we just check that the middleware itself works
+				methods: tt.methods,
 			}
 
 			req, err := http.NewRequest("GET", "/needed-headers", nil)
@@ -132,9 +209,10 @@ func Test_neededHeadersMiddleware(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			h := neededHeadersMiddleware(tt.f).(neededHeaders)
+			h := neededHeadersMiddleware([]string{MethodKey})(tt.f).(neededHeaders)
 			require.NotNil(t, h)
 			require.NotNil(t, h.next)
+			require.Equal(t, []string{MethodKey}, h.methods)
 		})
 	}
 }
@@ -0,0 +1,85 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Identity is the identity a request to a protected handler has been authenticated with.
+type Identity struct {
+	// DID is the authenticated DID.
+	DID string
+
+	// OnBehalfOf is the DID DID acts on behalf of, if the token has been released for a Delegation. Handlers should
+	// apply its permissions.
+	OnBehalfOf string
+
+	// Resource is the resource the token has been released for, and Grants the resources and methods it gives access
+	// to, if any.
+	Resource string
+	Grants   []Grant
+
+	// Membership is the commercio.network membership level of DID, if the token carries it.
+	Membership string
+
+	// TokenID is the jti claim of the token, empty for tokens released by older versions.
+	TokenID string
+
+	// ExpiresAt is the time the token expires at.
+	ExpiresAt time.Time
+
+	// Claims holds every claim of the token.
+	Claims map[string]interface{}
+}
+
+// identityContextKey is the request context key Identity is stored under.
+type identityContextKey struct{}
+
+// IdentityFromContext returns the Identity stored in ctx by the authentication middleware of protected handlers.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityContextKey{}).(Identity)
+	return id, ok
+}
+
+// contextWithIdentity returns a copy of ctx holding id.
+func contextWithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, id)
+}
+
+// newIdentity returns the Identity described by claims, parsed from the verified token bearer.
+func newIdentity(bearer string, claims *DidComAuthClaims) Identity {
+	return Identity{
+		DID:        claims.DID,
+		OnBehalfOf: claims.OnBehalfOf,
+		Resource:   claims.Resource,
+		Grants:     claims.Grants,
+		Membership: claims.Membership,
+		TokenID:    claims.Id,
+		ExpiresAt:  time.Unix(claims.ExpiresAt, 0),
+		Claims:     rawClaims(bearer),
+	}
+}
+
+// rawClaims returns the claims of the verified token bearer as they are, nil if they can't be decoded.
+func rawClaims(bearer string) map[string]interface{} {
+	parts := strings.Split(bearer, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	payload, err := jwt.DecodeSegment(parts[1])
+	if err != nil {
+		return nil
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil
+	}
+
+	return claims
+}
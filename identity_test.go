@@ -0,0 +1,70 @@
+package didcomauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityFromContext(t *testing.T) {
+	_, ok := IdentityFromContext(context.Background())
+	require.False(t, ok)
+
+	id := Identity{DID: "did", Resource: "/path"}
+	got, ok := IdentityFromContext(contextWithIdentity(context.Background(), id))
+	require.True(t, ok)
+	require.Equal(t, id, got)
+}
+
+func Test_rawClaims(t *testing.T) {
+	require.Nil(t, rawClaims("invalid"))
+	require.Nil(t, rawClaims("a.!!!.c"))
+
+	token, err := genJWT(&DidComAuthClaims{Resource: "/path", DID: "did"}, time.Minute, JWTKey{Algorithm: AlgorithmHS512, Key: []byte("secret")})
+	require.NoError(t, err)
+
+	claims := rawClaims(token)
+	require.Equal(t, "did", claims["did"])
+	require.Equal(t, "/path", claims["resource"])
+	require.Contains(t, claims, "jti")
+}
+
+func Test_checkAuth_identity(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{config: Config{JWTSecret: "secret"}, cp: newCTest(false)}
+
+	grants := []Grant{{Resource: "/path", Methods: []string{http.MethodGet}}}
+	resp, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: did, Resource: "/path", Grants: grants}, time.Time{})
+	require.NoError(t, err)
+
+	var id Identity
+	var found bool
+	protected := checkAuth{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id, found = IdentityFromContext(req.Context())
+			w.WriteHeader(http.StatusOK)
+		}),
+		r: r,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/path", nil)
+	req.Header.Set(authHeader, "Bearer "+resp.Token)
+	req.Header.Set(DIDHeader, did)
+	req.Header.Set(ResourceHeader, "/path")
+
+	rr := httptest.NewRecorder()
+	protected.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	require.True(t, found)
+	require.Equal(t, did, id.DID)
+	require.Equal(t, "/path", id.Resource)
+	require.Equal(t, grants, id.Grants)
+	require.NotEmpty(t, id.TokenID)
+	require.Equal(t, id.TokenID, id.Claims["jti"])
+	require.WithinDuration(t, time.Now().Add(jwtTokenExpiry), id.ExpiresAt, 2*time.Second)
+}
@@ -0,0 +1,104 @@
+package didcomauth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+const defaultIntrospectionPath = "/introspect"
+
+// IntrospectionResponse is the response of the introspection endpoint (RFC 7662).
+// Only Active is set for tokens that aren't active: invalid, expired or revoked ones.
+type IntrospectionResponse struct {
+	Active     bool    `json:"active"`
+	TokenType  string  `json:"token_type,omitempty"`
+	Subject    string  `json:"sub,omitempty"`
+	DID        string  `json:"did,omitempty"`
+	OnBehalfOf string  `json:"on_behalf_of,omitempty"`
+	Resource   string  `json:"resource,omitempty"`
+	Grants     []Grant `json:"grants,omitempty"`
+	Membership string  `json:"membership,omitempty"`
+	Issuer     string  `json:"iss,omitempty"`
+	Audience   string  `json:"aud,omitempty"`
+	TokenID    string  `json:"jti,omitempty"`
+	IssuedAt   int64   `json:"iat,omitempty"`
+	NotBefore  int64   `json:"nbf,omitempty"`
+	ExpiresAt  int64   `json:"exp,omitempty"`
+}
+
+// introspectionClientAuthenticated returns true if req carries the HTTP Basic credentials of one of the
+// Config.IntrospectionClients.
+func (r *router) introspectionClientAuthenticated(req *http.Request) bool {
+	id, secret, ok := req.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	expected, ok := r.config.IntrospectionClients[id]
+	if !ok || expected == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) == 1
+}
+
+// introspectionPOSTHandler tells whether the token in the token form parameter is active and, if so, what it grants.
+func (r *router) introspectionPOSTHandler(rw http.ResponseWriter, req *http.Request) {
+	if !r.introspectionClientAuthenticated(req) {
+		rw.Header().Set("WWW-Authenticate", `Basic realm="didcomauth"`)
+		writeError(rw, http.StatusUnauthorized, errors.New("invalid client credentials"))
+		return
+	}
+
+	if err := req.ParseForm(); err != nil {
+		writeError(rw, http.StatusBadRequest, fmt.Errorf("could not parse form, %w", err))
+		return
+	}
+
+	bearer := req.PostForm.Get("token")
+	if bearer == "" {
+		writeError(rw, http.StatusBadRequest, errors.New("token parameter empty"))
+		return
+	}
+
+	resp := IntrospectionResponse{}
+	if claims, err := r.parseToken(bearer); err == nil {
+		revoked, err := r.claimsRevoked(req.Context(), *claims, claims.IssuedAt)
+		if err != nil {
+			log.Println(err)
+			writeError(rw, http.StatusInternalServerError, errors.New("could not check token revocation"))
+			return
+		}
+
+		if !revoked {
+			resp = IntrospectionResponse{
+				Active:     true,
+				TokenType:  "Bearer",
+				Subject:    claims.Subject,
+				DID:        claims.DID,
+				OnBehalfOf: claims.OnBehalfOf,
+				Resource:   claims.Resource,
+				Grants:     claims.Grants,
+				Membership: claims.Membership,
+				Issuer:     claims.Issuer,
+				Audience:   claims.Audience,
+				TokenID:    claims.Id,
+				IssuedAt:   claims.IssuedAt,
+				NotBefore:  claims.NotBefore,
+				ExpiresAt:  claims.ExpiresAt,
+			}
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+
+	jenc := json.NewEncoder(rw)
+	if err := jenc.Encode(resp); err != nil {
+		writeError(rw, http.StatusInternalServerError, fmt.Errorf("could not marshal introspection response, %w", err))
+	}
+}
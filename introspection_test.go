@@ -0,0 +1,109 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func introspectionRequest(t *testing.T, r *router, id, secret, token string) *httptest.ResponseRecorder {
+	form := url.Values{}
+	if token != "" {
+		form.Set("token", token)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if id != "" {
+		req.SetBasicAuth(id, secret)
+	}
+
+	rr := httptest.NewRecorder()
+	r.introspectionPOSTHandler(rr, req)
+	return rr
+}
+
+func Test_router_introspectionPOSTHandler(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{
+		config: Config{
+			JWTSecret:            "secret",
+			Issuer:               "didcomauth",
+			Audience:             "upload",
+			IntrospectionClients: map[string]string{"python": "hunter2"},
+		},
+		cp: newCTest(false),
+	}
+
+	resp, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: did, Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+
+	// clients must authenticate
+	for _, credentials := range [][2]string{{"", ""}, {"python", "wrong"}, {"node", "hunter2"}} {
+		rr := introspectionRequest(t, r, credentials[0], credentials[1], resp.Token)
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+	}
+
+	rr := introspectionRequest(t, r, "python", "hunter2", "")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = introspectionRequest(t, r, "python", "hunter2", resp.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	var ir IntrospectionResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &ir))
+	require.True(t, ir.Active)
+	require.Equal(t, did, ir.DID)
+	require.Equal(t, did, ir.Subject)
+	require.Equal(t, "/path", ir.Resource)
+	require.Equal(t, "didcomauth", ir.Issuer)
+	require.Equal(t, "upload", ir.Audience)
+	require.NotEmpty(t, ir.TokenID)
+	require.InDelta(t, time.Now().Add(jwtTokenExpiry).Unix(), ir.ExpiresAt, 2)
+
+	// invalid and revoked tokens aren't active, and nothing else is told about them
+	require.NoError(t, r.revokeToken(context.Background(), ir.TokenID, time.Unix(ir.ExpiresAt, 0)))
+	for _, token := range []string{"invalid", resp.Token} {
+		rr = introspectionRequest(t, r, "python", "hunter2", token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"active":false}`, rr.Body.String())
+	}
+}
+
+func TestConfigure_introspection(t *testing.T) {
+	config := Config{
+		JWTSecret: "secret",
+		ProtectedPaths: []ProtectedMapping{
+			{
+				Methods: []string{http.MethodGet},
+				Path:    "/get",
+				Handler: nil,
+			},
+		},
+		CacheType: CacheTypeMemory,
+	}
+
+	// the endpoint is disabled by default
+	m := mux.NewRouter()
+	require.NoError(t, Configure(config, m))
+	rr := httptest.NewRecorder()
+	m.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/auth/introspect", nil))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	config.IntrospectionClients = map[string]string{"python": "hunter2"}
+	m = mux.NewRouter()
+	require.NoError(t, Configure(config, m))
+	rr = httptest.NewRecorder()
+	m.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/auth/introspect", nil))
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
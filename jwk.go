@@ -0,0 +1,150 @@
+package didcomauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// JWK is a JSON Web Key (RFC 7517), limited to the public key types didcomauth works with.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+}
+
+// PublicKey returns the public key j represents.
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := jwkInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := jwkInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA public exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "secp256k1":
+			curve = btcec.S256()
+		default:
+			return nil, fmt.Errorf("curve %s not supported", j.Crv)
+		}
+
+		x, err := jwkInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := jwkInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC public key")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("curve %s not supported", j.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("key type %s not supported", j.Kty)
+	}
+}
+
+// jwkInt decodes a base64url-encoded big endian unsigned integer.
+func jwkInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid JWK parameter")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// jwkFromPublicKey returns the JWK representation of key.
+func jwkFromPublicKey(key crypto.PublicKey) (JWK, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(bigEndian(uint64(k.E))),
+		}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return JWK{}, errUnsupportedKey
+		}
+
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(padBytes(k.X.Bytes(), size)),
+			Y:   base64.RawURLEncoding.EncodeToString(padBytes(k.Y.Bytes(), size)),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	default:
+		return JWK{}, errUnsupportedKey
+	}
+}
+
+// bigEndian returns the minimal big endian representation of n.
+func bigEndian(n uint64) []byte {
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+
+	return b
+}
+
+// padBytes left-pads b with zeroes up to size bytes.
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	return append(make([]byte, size-len(b)), b...)
+}
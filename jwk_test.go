@@ -0,0 +1,127 @@
+package didcomauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+func b64Int(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func TestJWK_PublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	s256Key, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		jwk     JWK
+		want    crypto.PublicKey
+		wantErr bool
+	}{
+		{
+			"rsa",
+			JWK{Kty: "RSA", N: b64Int(rsaKey.N), E: b64Int(big.NewInt(int64(rsaKey.E)))},
+			&rsaKey.PublicKey,
+			false,
+		},
+		{
+			"p-256",
+			JWK{Kty: "EC", Crv: "P-256", X: b64Int(p256Key.X), Y: b64Int(p256Key.Y)},
+			&p256Key.PublicKey,
+			false,
+		},
+		{
+			"secp256k1",
+			JWK{Kty: "EC", Crv: "secp256k1", X: b64Int(s256Key.X), Y: b64Int(s256Key.Y)},
+			s256Key.PubKey().ToECDSA(),
+			false,
+		},
+		{
+			"ed25519",
+			JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(edKey)},
+			edKey,
+			false,
+		},
+		{
+			"point not on curve",
+			JWK{Kty: "EC", Crv: "P-256", X: b64Int(p256Key.X), Y: b64Int(p256Key.X)},
+			nil,
+			true,
+		},
+		{
+			"unsupported curve",
+			JWK{Kty: "EC", Crv: "P-384", X: b64Int(p256Key.X), Y: b64Int(p256Key.Y)},
+			nil,
+			true,
+		},
+		{
+			"unsupported okp curve",
+			JWK{Kty: "OKP", Crv: "X25519", X: base64.RawURLEncoding.EncodeToString(edKey)},
+			nil,
+			true,
+		},
+		{
+			"missing rsa modulus",
+			JWK{Kty: "RSA", E: "AQAB"},
+			nil,
+			true,
+		},
+		{
+			"unsupported key type",
+			JWK{Kty: "oct"},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.jwk.PublicKey()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, key)
+		})
+	}
+}
+
+func Test_jwkFromPublicKey(t *testing.T) {
+	for _, key := range testJWTKeys(t) {
+		pub, ok := key.publicKey()
+		if !ok {
+			continue
+		}
+
+		j, err := jwkFromPublicKey(pub)
+		require.NoError(t, err, key.ID)
+
+		got, err := j.PublicKey()
+		require.NoError(t, err, key.ID)
+		require.Equal(t, pub, got, key.ID)
+	}
+
+	s256Key, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	_, err = jwkFromPublicKey(s256Key.PubKey().ToECDSA())
+	require.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package didcomauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const jwksPath = "/.well-known/jwks.json"
+
+// JWKS is a JSON Web Key Set (RFC 7517), as served on /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwksHandler serves the public keys tokens can be verified with.
+// Tokens signed with HS512 secrets can only be verified by whoever holds the secret, hence those aren't listed.
+func (r *router) jwksHandler(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+
+	jenc := json.NewEncoder(rw)
+	if err := jenc.Encode(JWKS{Keys: r.keyRing().publicJWKs()}); err != nil {
+		writeError(rw, http.StatusInternalServerError, fmt.Errorf("could not marshal key set, %w", err))
+	}
+}
@@ -2,8 +2,10 @@ package didcomauth
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
@@ -20,55 +22,88 @@ type checkAuth struct {
 }
 
 func (c checkAuth) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	ah := req.Header.Get(authHeader)
 	did := req.Header.Get(DIDHeader)
 	resource := req.Header.Get(ResourceHeader)
 
-	if ah == "" {
+	bearer, fromCookie := c.r.requestToken(req)
+	if bearer == "" {
 		writeError(w, http.StatusForbidden, notAuthorized)
 		return
 	}
 
-	bearer := getBearer(ah)
-
-	if bearer == "" {
-		writeError(w, http.StatusForbidden, notAuthorized)
+	claims, err := c.r.parseToken(bearer)
+	if err != nil {
+		writeError(w, http.StatusForbidden, invalidTokenError)
 		return
 	}
 
-	token, tokenError := jwt.Parse(bearer, func(token *jwt.Token) (interface{}, error) {
-		if tt, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || tt.Name != "HS512" {
-			return nil, invalidTokenError
+	// browsers can't send custom headers on navigations, cookies are bound to the token claims anyway
+	if fromCookie {
+		if did == "" {
+			did = claims.DID
+		}
+
+		if resource == "" {
+			resource = claims.Resource
 		}
 
-		return []byte(c.r.config.JWTSecret), nil
-	})
+		if err := checkCSRF(req, claims); err != nil {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+	}
 
-	if tokenError != nil {
+	if claims.Resource != resource || claims.DID != did {
 		writeError(w, http.StatusForbidden, invalidTokenError)
 		return
 	}
 
-	var claimsErr error
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if claims["resource"] != resource || claims["did"] != did {
-			claimsErr = invalidTokenError
-		}
-	} else {
-		claimsErr = invalidTokenError
+	if !c.r.claimsAllow(claims, req.Method, req.URL.Path) {
+		writeError(w, http.StatusForbidden, invalidTokenError)
+		return
 	}
 
-	if claimsErr != nil {
-		writeError(w, http.StatusForbidden, claimsErr)
+	if !c.r.lifetimeAllows(claims, req.Method, req.URL.Path, time.Now()) {
+		writeError(w, http.StatusForbidden, invalidTokenError)
 		return
 	}
 
-	if resource != req.URL.Path {
+	if !c.r.membershipAllows(claims.Membership, req.Method, req.URL.Path) {
+		writeError(w, http.StatusForbidden, errMembershipTooLow)
+		return
+	}
+
+	if err := c.r.checkPolicy(claims.principal(), req.Method, req.URL.Path); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	revoked, err := c.r.claimsRevoked(req.Context(), *claims, claims.IssuedAt)
+	if err != nil {
+		log.Println(err)
+		writeError(w, http.StatusInternalServerError, errors.New("could not check token revocation"))
+		return
+	}
+
+	if revoked {
 		writeError(w, http.StatusForbidden, invalidTokenError)
 		return
 	}
 
-	c.next.ServeHTTP(w, req)
+	c.next.ServeHTTP(w, req.WithContext(contextWithIdentity(req.Context(), newIdentity(bearer, claims))))
+}
+
+// tokenKey returns the key token must be verified with, selected by its kid header.
+// The algorithm token is signed with must be the one of the key, to prevent algorithm substitution.
+func (r *router) tokenKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := r.keyRing().verificationKey(kid)
+	if !ok || token.Method == nil || token.Method.Alg() != key.Algorithm {
+		return nil, invalidTokenError
+	}
+
+	return key.verificationKey(), nil
 }
 
 func (r *router) checkAuthMiddleware(next http.Handler) http.Handler {
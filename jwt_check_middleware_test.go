@@ -71,6 +71,7 @@ func Test_checkAuth_ServeHTTP(t *testing.T) {
 		config: Config{
 			JWTSecret: "secret",
 		},
+		cp: newCTest(false),
 	}
 
 	tests := []struct {
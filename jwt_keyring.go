@@ -0,0 +1,146 @@
+package didcomauth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// keyRing holds the key released tokens are signed with, and the keys tokens are still accepted from.
+// Keys are selected by the kid header of tokens, tokens signed with Config.JWTSecret carry no kid and its key has an
+// empty ID.
+type keyRing struct {
+	mu           sync.RWMutex
+	signing      JWTKey
+	verification []JWTKey
+}
+
+// keyRing returns the key ring of r, built from its configuration on first use.
+func (r *router) keyRing() *keyRing {
+	r.keysOnce.Do(func() {
+		r.keys = newKeyRing(r.config)
+	})
+
+	return r.keys
+}
+
+// newKeyRing returns a key ring signing with the key configured in c, and verifying with the verification keys and
+// JWTSecret, if set.
+func newKeyRing(c Config) *keyRing {
+	kr := &keyRing{
+		signing:      c.tokenSigningKey(),
+		verification: append([]JWTKey(nil), c.JWTVerificationKeys...),
+	}
+
+	if c.JWTSigningKey != nil && c.JWTSecret != "" {
+		kr.verification = append(kr.verification, JWTKey{Algorithm: AlgorithmHS512, Key: []byte(c.JWTSecret)})
+	}
+
+	return kr
+}
+
+// signingKey returns the key released tokens are signed with.
+func (kr *keyRing) signingKey() JWTKey {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	return kr.signing
+}
+
+// verificationKey returns the key tokens with kid in their header are verified with.
+func (kr *keyRing) verificationKey(kid string) (JWTKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	if kr.signing.ID == kid {
+		return kr.signing, true
+	}
+
+	for _, k := range kr.verification {
+		if k.ID == kid {
+			return k, true
+		}
+	}
+
+	return JWTKey{}, false
+}
+
+// publicJWKs returns the JWKs of the asymmetric keys tokens can be verified with, the signing key first.
+func (kr *keyRing) publicJWKs() []JWK {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	keys := []JWK{}
+	for _, k := range append([]JWTKey{kr.signing}, kr.verification...) {
+		if j, ok := k.publicJWK(); ok {
+			keys = append(keys, j)
+		}
+	}
+
+	return keys
+}
+
+// rotate makes key the signing key, the current signing key is kept for verification.
+func (kr *keyRing) rotate(key JWTKey) error {
+	if err := key.validate(); err != nil {
+		return err
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	if kr.signing.ID == key.ID {
+		return fmt.Errorf("jwt key %s is already the signing key", key.ID)
+	}
+
+	verification := []JWTKey{kr.signing}
+	for _, k := range kr.verification {
+		if k.ID != key.ID {
+			verification = append(verification, k)
+		}
+	}
+
+	kr.signing = key
+	kr.verification = verification
+	return nil
+}
+
+// retire removes the verification key with the given ID, tokens signed with it aren't accepted anymore.
+func (kr *keyRing) retire(id string) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	if kr.signing.ID == id {
+		return errors.New("the signing key can't be retired, rotate it first")
+	}
+
+	for i, k := range kr.verification {
+		if k.ID == id {
+			kr.verification = append(kr.verification[:i:i], kr.verification[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("jwt key %s not found", id)
+}
+
+// RotateJWTKey makes key the key tokens are signed with from now on, through the instance set up by Configure.
+// The previous signing key is kept to verify the tokens it signed, until retired with RetireJWTKey.
+// Keys are rotated in this process only, every replica of a service must rotate its own.
+func RotateJWTKey(key JWTKey) error {
+	if instance == nil {
+		return errNotConfigured
+	}
+
+	return instance.keyRing().rotate(key)
+}
+
+// RetireJWTKey stops accepting the tokens signed with the key with the given ID, through the instance set up by
+// Configure. Config.JWTSecret has an empty ID.
+func RetireJWTKey(id string) error {
+	if instance == nil {
+		return errNotConfigured
+	}
+
+	return instance.keyRing().retire(id)
+}
@@ -0,0 +1,89 @@
+package didcomauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_keyRing(t *testing.T) {
+	keys := testJWTKeys(t)
+	es256, eddsa, hs512 := keys[2], keys[3], keys[4]
+
+	kr := newKeyRing(Config{JWTSecret: "legacy", JWTSigningKey: &es256, JWTVerificationKeys: []JWTKey{hs512}})
+	require.Equal(t, es256, kr.signingKey())
+
+	for _, kid := range []string{"es256", "hs512", ""} {
+		_, ok := kr.verificationKey(kid)
+		require.True(t, ok, kid)
+	}
+
+	_, ok := kr.verificationKey("eddsa")
+	require.False(t, ok)
+
+	// the previous signing key is kept for verification
+	require.NoError(t, kr.rotate(eddsa))
+	require.Equal(t, eddsa, kr.signingKey())
+	_, ok = kr.verificationKey("es256")
+	require.True(t, ok)
+
+	jwks := kr.publicJWKs()
+	require.Len(t, jwks, 2)
+	require.Equal(t, "eddsa", jwks[0].Kid)
+	require.Equal(t, "es256", jwks[1].Kid)
+
+	require.Error(t, kr.rotate(eddsa), "already the signing key")
+	require.Error(t, kr.rotate(JWTKey{ID: "invalid", Algorithm: AlgorithmHS512}))
+
+	// rotating back to a verification key promotes it
+	require.NoError(t, kr.rotate(hs512))
+	require.Equal(t, hs512, kr.signingKey())
+	_, ok = kr.verificationKey("hs512")
+	require.True(t, ok)
+
+	require.Error(t, kr.retire("hs512"), "the signing key can't be retired")
+	require.Error(t, kr.retire("unknown"))
+
+	for _, kid := range []string{"es256", ""} {
+		require.NoError(t, kr.retire(kid))
+		_, ok = kr.verificationKey(kid)
+		require.False(t, ok, kid)
+	}
+
+	_, ok = kr.verificationKey("eddsa")
+	require.True(t, ok)
+}
+
+func TestRotateJWTKey(t *testing.T) {
+	keys := testJWTKeys(t)
+	r := &router{config: Config{JWTSecret: "secret"}, cp: newCTest(false)}
+
+	old := instance
+	defer func() { instance = old }()
+
+	instance = nil
+	require.Equal(t, errNotConfigured, RotateJWTKey(keys[3]))
+	require.Equal(t, errNotConfigured, RetireJWTKey(""))
+
+	instance = r
+	legacy, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: "did", Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+
+	// tokens signed before the rotation stay valid until their key is retired
+	require.NoError(t, RotateJWTKey(keys[3]))
+	rotated, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: "did", Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+
+	for _, token := range []string{legacy.Token, rotated.Token} {
+		_, err := r.parseToken(token)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, RetireJWTKey(""))
+	_, err = r.parseToken(legacy.Token)
+	require.Error(t, err)
+	_, err = r.parseToken(rotated.Token)
+	require.NoError(t, err)
+}
@@ -0,0 +1,185 @@
+package didcomauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// AlgorithmHS512 is HMAC using SHA-512, which tokens are signed with when JWTSecret is used.
+// It can't be used to sign challenge responses.
+const AlgorithmHS512 = "HS512"
+
+// JWTKey is a key the tokens released by didcomauth are signed with.
+type JWTKey struct {
+	// ID is written in the kid header of the tokens the key signs, and selects the key when verifying them.
+	ID string
+
+	// Algorithm is the JWA identifier of the algorithm tokens are signed with: RS256, PS256, ES256, EdDSA or HS512.
+	Algorithm string
+
+	// Key is an *rsa.PrivateKey for RS256 and PS256, a P-256 *ecdsa.PrivateKey for ES256, an ed25519.PrivateKey for
+	// EdDSA and a []byte secret for HS512, other crypto.Signer implementations are rejected.
+	Key interface{}
+}
+
+// jwtAlgorithmKinds maps each algorithm tokens can be signed with to the kind of key it needs, HS512 is handled
+// separately.
+var jwtAlgorithmKinds = map[string]string{
+	AlgorithmRS256: keyKindRSA,
+	AlgorithmPS256: keyKindRSA,
+	AlgorithmES256: keyKindP256,
+	AlgorithmEdDSA: keyKindEd25519,
+}
+
+// validate checks that k has an ID and a key suitable for its algorithm.
+func (k JWTKey) validate() error {
+	if k.ID == "" {
+		return errors.New("jwt key without ID")
+	}
+
+	if k.Algorithm == AlgorithmHS512 {
+		if secret, ok := k.Key.([]byte); !ok || len(secret) == 0 {
+			return fmt.Errorf("jwt key %s must be a non-empty []byte secret", k.ID)
+		}
+
+		return nil
+	}
+
+	kind, ok := jwtAlgorithmKinds[k.Algorithm]
+	if !ok {
+		return fmt.Errorf("jwt key %s algorithm %s not supported", k.ID, k.Algorithm)
+	}
+
+	pub, ok := k.publicKey()
+	if !ok {
+		return fmt.Errorf("jwt key %s must be a private key", k.ID)
+	}
+
+	if keyKind, err := keyKind(pub); err != nil || keyKind != kind {
+		return fmt.Errorf("jwt key %s can't be used with algorithm %s", k.ID, k.Algorithm)
+	}
+
+	return nil
+}
+
+// publicKey returns the public counterpart of k, if k is a private key of a type tokens can be signed with: an
+// *rsa.PrivateKey, an *ecdsa.PrivateKey or an ed25519.PrivateKey. Other crypto.Signer implementations can't be used.
+func (k JWTKey) publicKey() (crypto.PublicKey, bool) {
+	if k.Algorithm == AlgorithmHS512 {
+		return nil, false
+	}
+
+	switch key := k.Key.(type) {
+	case *rsa.PrivateKey:
+		if key == nil {
+			return nil, false
+		}
+
+		return key.Public(), true
+	case *ecdsa.PrivateKey:
+		if key == nil {
+			return nil, false
+		}
+
+		return key.Public(), true
+	case ed25519.PrivateKey:
+		if len(key) != ed25519.PrivateKeySize {
+			return nil, false
+		}
+
+		return key.Public(), true
+	default:
+		return nil, false
+	}
+}
+
+// method returns the jwt-go signing method of k.
+func (k JWTKey) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// verificationKey returns the key jwt-go verifies the tokens signed with k with.
+func (k JWTKey) verificationKey() interface{} {
+	if pub, ok := k.publicKey(); ok {
+		return pub
+	}
+
+	return k.Key
+}
+
+// signingMethodEdDSA is the jwt-go signing method for Ed25519, which jwt-go doesn't ship.
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(AlgorithmEdDSA, func() jwt.SigningMethod {
+		return signingMethodEdDSA{}
+	})
+}
+
+// Alg implements the jwt.SigningMethod interface.
+func (signingMethodEdDSA) Alg() string {
+	return AlgorithmEdDSA
+}
+
+// Sign implements the jwt.SigningMethod interface.
+func (signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	k, ok := key.(ed25519.PrivateKey)
+	if !ok || len(k) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(k, []byte(signingString))), nil
+}
+
+// Verify implements the jwt.SigningMethod interface.
+func (signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	k, ok := key.(ed25519.PublicKey)
+	if !ok || len(k) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(k, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+// publicJWK returns the JWK of the public key of k, if k is an asymmetric key.
+func (k JWTKey) publicJWK() (JWK, bool) {
+	pub, ok := k.publicKey()
+	if !ok {
+		return JWK{}, false
+	}
+
+	j, err := jwkFromPublicKey(pub)
+	if err != nil {
+		return JWK{}, false
+	}
+
+	j.Kid = k.ID
+	j.Alg = k.Algorithm
+	j.Use = "sig"
+
+	return j, true
+}
+
+// tokenSigningKey returns the key released tokens are signed with.
+func (c Config) tokenSigningKey() JWTKey {
+	if c.JWTSigningKey != nil {
+		return *c.JWTSigningKey
+	}
+
+	return JWTKey{Algorithm: AlgorithmHS512, Key: []byte(c.JWTSecret)}
+}
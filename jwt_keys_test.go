@@ -0,0 +1,173 @@
+package didcomauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+)
+
+// testJWTKeys returns a valid JWTKey for each supported algorithm.
+func testJWTKeys(t *testing.T) []JWTKey {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return []JWTKey{
+		{ID: "rs256", Algorithm: AlgorithmRS256, Key: rsaKey},
+		{ID: "ps256", Algorithm: AlgorithmPS256, Key: rsaKey},
+		{ID: "es256", Algorithm: AlgorithmES256, Key: p256Key},
+		{ID: "eddsa", Algorithm: AlgorithmEdDSA, Key: edKey},
+		{ID: "hs512", Algorithm: AlgorithmHS512, Key: []byte("secret")},
+	}
+}
+
+// opaqueSigner is a crypto.Signer of a type JWTKey doesn't support, such as the ones backed by HSMs.
+type opaqueSigner struct {
+	crypto.Signer
+}
+
+func TestJWTKey_validate(t *testing.T) {
+	keys := testJWTKeys(t)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	edKey := keys[3].Key.(ed25519.PrivateKey)
+
+	for _, k := range keys {
+		require.NoError(t, k.validate(), k.ID)
+	}
+
+	tests := []struct {
+		name string
+		key  JWTKey
+	}{
+		{"no ID", JWTKey{Algorithm: AlgorithmEdDSA, Key: keys[3].Key}},
+		{"unsupported algorithm", JWTKey{ID: "k", Algorithm: "HS256", Key: []byte("secret")}},
+		{"empty secret", JWTKey{ID: "k", Algorithm: AlgorithmHS512, Key: []byte{}}},
+		{"public key", JWTKey{ID: "k", Algorithm: AlgorithmRS256, Key: keys[0].Key.(*rsa.PrivateKey).Public()}},
+		{"key not matching algorithm", JWTKey{ID: "k", Algorithm: AlgorithmES256, Key: keys[0].Key}},
+		{"unsupported curve", JWTKey{ID: "k", Algorithm: AlgorithmES256, Key: p384Key}},
+		{"other signer", JWTKey{ID: "k", Algorithm: AlgorithmEdDSA, Key: opaqueSigner{edKey}}},
+		{"ed25519 key pointer", JWTKey{ID: "k", Algorithm: AlgorithmEdDSA, Key: &edKey}},
+		{"short ed25519 key", JWTKey{ID: "k", Algorithm: AlgorithmEdDSA, Key: edKey[:32]}},
+		{"nil key", JWTKey{ID: "k", Algorithm: AlgorithmRS256, Key: (*rsa.PrivateKey)(nil)}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.key.validate())
+		})
+	}
+}
+
+func Test_genJWT_keys(t *testing.T) {
+	for _, key := range testJWTKeys(t) {
+		key := key
+		t.Run(key.ID, func(t *testing.T) {
+			r := &router{config: Config{JWTSigningKey: &key}}
+
+			signed, err := genJWT(&DidComAuthClaims{Resource: "/path", DID: "did"}, time.Minute, r.keyRing().signingKey())
+			require.NoError(t, err)
+
+			token, err := jwt.Parse(signed, r.tokenKey)
+			require.NoError(t, err)
+			require.True(t, token.Valid)
+			require.Equal(t, key.ID, token.Header["kid"])
+			require.Equal(t, key.Algorithm, token.Header["alg"])
+		})
+	}
+}
+
+func Test_router_tokenKey(t *testing.T) {
+	keys := testJWTKeys(t)
+	rsaKey := keys[0]
+
+	rsaSigned, err := genJWT(&DidComAuthClaims{Resource: "/path", DID: "did"}, time.Minute, rsaKey)
+	require.NoError(t, err)
+	legacySigned, err := genJWT(&DidComAuthClaims{Resource: "/path", DID: "did"}, time.Minute, JWTKey{Algorithm: AlgorithmHS512, Key: []byte("secret")})
+	require.NoError(t, err)
+
+	// an HS512 token claiming the RSA key kid, signed with the RSA public key as secret
+	confused := jwt.New(jwt.SigningMethodHS512)
+	confused.Header["kid"] = rsaKey.ID
+	confusedSigned, err := confused.SignedString([]byte("public key bytes"))
+	require.NoError(t, err)
+
+	unknownKid, err := genJWT(&DidComAuthClaims{Resource: "/path", DID: "did"}, time.Minute, JWTKey{ID: "unknown", Algorithm: AlgorithmEdDSA, Key: keys[3].Key})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		config  Config
+		token   string
+		wantErr bool
+	}{
+		{"signing key", Config{JWTSigningKey: &rsaKey}, rsaSigned, false},
+		{"legacy secret still accepted", Config{JWTSecret: "secret", JWTSigningKey: &rsaKey}, legacySigned, false},
+		{"legacy secret not set", Config{JWTSigningKey: &rsaKey}, legacySigned, true},
+		{"algorithm substitution", Config{JWTSigningKey: &rsaKey}, confusedSigned, true},
+		{"unknown kid", Config{JWTSecret: "secret", JWTSigningKey: &rsaKey}, unknownKid, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r := &router{config: tt.config}
+			_, err := jwt.Parse(tt.token, r.tokenKey)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_router_jwksHandler(t *testing.T) {
+	keys := testJWTKeys(t)
+
+	tests := []struct {
+		name     string
+		config   Config
+		wantKeys int
+	}{
+		{"HS512 secret isn't published", Config{JWTSecret: "secret"}, 0},
+		{"HS512 key isn't published", Config{JWTSigningKey: &keys[4]}, 0},
+		{"EdDSA key", Config{JWTSigningKey: &keys[3]}, 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r := &router{config: tt.config}
+			rr := httptest.NewRecorder()
+			r.jwksHandler(rr, httptest.NewRequest(http.MethodGet, jwksPath, nil))
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			var set JWKS
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &set))
+			require.Len(t, set.Keys, tt.wantKeys)
+
+			for _, j := range set.Keys {
+				require.Equal(t, tt.config.JWTSigningKey.ID, j.Kid)
+				require.Equal(t, "sig", j.Use)
+
+				pub, err := j.PublicKey()
+				require.NoError(t, err)
+				require.Equal(t, tt.config.JWTSigningKey.verificationKey(), pub)
+			}
+		})
+	}
+}
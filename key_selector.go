@@ -0,0 +1,74 @@
+package didcomauth
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+)
+
+// KeySelector selects the verification method of a DID document a challenge response must be verified with.
+type KeySelector interface {
+	// SelectKey returns the verification method of doc to verify a challenge response with.
+	// kid is the ID of the key the client says it signed the response with, and might be empty.
+	SelectKey(doc DIDDocument, kid string) (VerificationMethod, error)
+}
+
+// KeySelectorFunc is an adapter to use ordinary functions as KeySelector.
+type KeySelectorFunc func(doc DIDDocument, kid string) (VerificationMethod, error)
+
+// SelectKey implements the KeySelector interface.
+func (f KeySelectorFunc) SelectKey(doc DIDDocument, kid string) (VerificationMethod, error) {
+	return f(doc, kid)
+}
+
+// AuthenticationKeySelector is the default KeySelector.
+//
+// Candidate keys are the ones listed in the document authentication relationship. Documents without an
+// authentication relationship, like commercio.network ones, have all their keys as candidates except the RSA
+// encryption key.
+// If kid is specified the candidate with that ID is selected, otherwise the first candidate in document order is.
+type AuthenticationKeySelector struct{}
+
+// SelectKey implements the KeySelector interface.
+func (AuthenticationKeySelector) SelectKey(doc DIDDocument, kid string) (VerificationMethod, error) {
+	candidates := doc.AuthenticationMethods()
+	if len(doc.Authentication) == 0 {
+		for _, m := range doc.Methods() {
+			if m.Type != KeyTypeRsaVerification {
+				candidates = append(candidates, m)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return VerificationMethod{}, errors.New("DDO doesn't have a verification key")
+	}
+
+	if kid == "" {
+		return candidates[0], nil
+	}
+
+	kid = doc.absoluteID(kid)
+	for _, c := range candidates {
+		if doc.absoluteID(c.ID) == kid {
+			return c, nil
+		}
+	}
+
+	return VerificationMethod{}, fmt.Errorf("key %s can't be used to authenticate %s", kid, doc.ID)
+}
+
+// signingKey returns the public key ks selects in doc for kid.
+func signingKey(ks KeySelector, doc DIDDocument, kid string) (crypto.PublicKey, error) {
+	vm, err := ks.SelectKey(doc, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := vm.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("invalid verification key %s, %w", vm.ID, err)
+	}
+
+	return key, nil
+}
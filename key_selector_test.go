@@ -0,0 +1,106 @@
+package didcomauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticationKeySelector_SelectKey(t *testing.T) {
+	commercioDoc := DIDDocument{
+		ID: "did:com:1",
+		PublicKeys: []VerificationMethod{
+			{ID: "did:com:1#keys-1", Type: KeyTypeRsaVerification},
+			{ID: "did:com:1#keys-2", Type: KeyTypeRsaSignature},
+			{ID: "did:com:1#keys-3", Type: KeyTypeEd25519},
+		},
+	}
+
+	w3cDoc := DIDDocument{
+		ID: "did:example:1",
+		VerificationMethod: []VerificationMethod{
+			{ID: "#assert", Type: KeyTypeEd25519},
+			{ID: "#auth-old", Type: KeyTypeEd25519},
+			{ID: "#auth-new", Type: KeyTypeEd25519},
+		},
+		Authentication: []VerificationRelationship{
+			{Reference: "#auth-new"},
+			{Reference: "did:example:1#auth-old"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		doc     DIDDocument
+		kid     string
+		wantID  string
+		wantErr bool
+	}{
+		{"commercio document, no kid", commercioDoc, "", "did:com:1#keys-2", false},
+		{"commercio document, kid", commercioDoc, "did:com:1#keys-3", "did:com:1#keys-3", false},
+		{"commercio document, relative kid", commercioDoc, "#keys-3", "did:com:1#keys-3", false},
+		{"commercio document, encryption key", commercioDoc, "#keys-1", "", true},
+		{"commercio document, unknown kid", commercioDoc, "#keys-9", "", true},
+		{"w3c document, no kid", w3cDoc, "", "#auth-new", false},
+		{"w3c document, rotated key", w3cDoc, "#auth-old", "#auth-old", false},
+		{"w3c document, key not in authentication", w3cDoc, "#assert", "", true},
+		{"empty document", DIDDocument{}, "", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			vm, err := AuthenticationKeySelector{}.SelectKey(tt.doc, tt.kid)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantID, vm.ID)
+		})
+	}
+}
+
+func Test_signingKey(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	doc := DIDDocument{
+		ID: "did:com:1",
+		PublicKeys: []VerificationMethod{
+			{ID: "did:com:1#keys-2", Type: KeyTypeEd25519, PublicKeyPem: testKeyPEM(t, edKey)},
+			{ID: "did:com:1#keys-3", Type: KeyTypeRsaSignature, PublicKeyPem: testKeyPEM(t, edKey)},
+		},
+	}
+
+	failing := KeySelectorFunc(func(doc DIDDocument, kid string) (VerificationMethod, error) {
+		return VerificationMethod{}, errors.New("no key for you")
+	})
+
+	tests := []struct {
+		name    string
+		ks      KeySelector
+		kid     string
+		wantErr bool
+	}{
+		{"default selector", AuthenticationKeySelector{}, "", false},
+		{"selected key is invalid", AuthenticationKeySelector{}, "#keys-3", true},
+		{"selector fails", failing, "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := signingKey(tt.ks, doc, tt.kid)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, edKey, key)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package didcomauth
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// mappingMatcher returns a router matching protected resources paths to the index of the ProtectedMapping serving
+// them, used to apply per-mapping settings to the tokens released for a resource.
+func (r *router) mappingMatcher() *mux.Router {
+	r.mappingsOnce.Do(func() {
+		r.mappings = mux.NewRouter()
+
+		routes := r.mappings
+		if base := r.config.ProtectedBasePath; base != "" {
+			routes = r.mappings.PathPrefix(base).Subrouter()
+		}
+
+		for i, mapping := range r.config.ProtectedPaths {
+			methods := mapping.Methods
+			routes.Path(mapping.Path).MatcherFunc(func(req *http.Request, _ *mux.RouteMatch) bool {
+				return req.Method == "" || containsString(methods, req.Method)
+			}).Name(strconv.Itoa(i))
+		}
+	})
+
+	return r.mappings
+}
+
+// mappingFor returns the ProtectedMapping serving resource with method, or with any method if method is empty.
+func (r *router) mappingFor(resource, method string) (ProtectedMapping, bool) {
+	var match mux.RouteMatch
+	req := &http.Request{Method: method, URL: &url.URL{Path: resource}}
+	if !r.mappingMatcher().Match(req, &match) || match.Route == nil {
+		return ProtectedMapping{}, false
+	}
+
+	i, err := strconv.Atoi(match.Route.GetName())
+	if err != nil {
+		return ProtectedMapping{}, false
+	}
+
+	return r.config.ProtectedPaths[i], true
+}
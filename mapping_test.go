@@ -0,0 +1,63 @@
+package didcomauth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_router_mappingFor(t *testing.T) {
+	r := &router{config: Config{
+		ProtectedBasePath: "/protected",
+		ProtectedPaths: []ProtectedMapping{
+			{Methods: []string{http.MethodGet}, Path: "/upload/{id}", TokenLifetime: time.Hour},
+			{Methods: []string{http.MethodPost}, Path: "/upload/{id}", TokenLifetime: time.Minute},
+			{Methods: []string{http.MethodGet}, Path: "/profile"},
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		resource  string
+		method    string
+		wantFound bool
+		wantIndex int
+	}{
+		{"template, any method", "/protected/upload/12", "", true, 0},
+		{"template, method", "/protected/upload/12", http.MethodPost, true, 1},
+		{"plain path", "/protected/profile", http.MethodGet, true, 2},
+		{"method not mapped", "/protected/profile", http.MethodDelete, false, 0},
+		{"outside the base path", "/upload/12", "", false, 0},
+		{"unknown path", "/protected/unknown", "", false, 0},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := r.mappingFor(tt.resource, tt.method)
+			require.Equal(t, tt.wantFound, ok)
+			if tt.wantFound {
+				require.Equal(t, r.config.ProtectedPaths[tt.wantIndex].Methods, m.Methods)
+				require.Equal(t, r.config.ProtectedPaths[tt.wantIndex].TokenLifetime, m.TokenLifetime)
+			}
+		})
+	}
+}
+
+func Test_router_tokenLifetime(t *testing.T) {
+	paths := []ProtectedMapping{
+		{Methods: []string{http.MethodGet}, Path: "/long", TokenLifetime: time.Hour},
+		{Methods: []string{http.MethodGet}, Path: "/default"},
+	}
+
+	r := &router{config: Config{ProtectedBasePath: "/protected", ProtectedPaths: paths}}
+	require.Equal(t, jwtTokenExpiry, r.tokenLifetime("/protected/default"))
+	require.Equal(t, time.Hour, r.tokenLifetime("/protected/long"))
+
+	r = &router{config: Config{ProtectedBasePath: "/protected", ProtectedPaths: paths, TokenLifetime: 5 * time.Minute}}
+	require.Equal(t, 5*time.Minute, r.tokenLifetime("/protected/default"))
+	require.Equal(t, 5*time.Minute, r.tokenLifetime("/protected/unknown"))
+	require.Equal(t, time.Hour, r.tokenLifetime("/protected/long"))
+	require.Equal(t, 5*time.Minute, r.tokenLifetime("/protected/long", "/protected/default"), "the shortest lifetime wins")
+}
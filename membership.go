@@ -0,0 +1,132 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// commercio.network membership levels, from the lowest to the highest.
+const (
+	MembershipBronze = "bronze"
+	MembershipSilver = "silver"
+	MembershipGold   = "gold"
+	MembershipBlack  = "black"
+)
+
+const (
+	comMembershipPath = "%s/membership/%s"
+	membershipClaim   = "membership"
+)
+
+var membershipLevels = []string{MembershipBronze, MembershipSilver, MembershipGold, MembershipBlack}
+
+var errMembershipTooLow = errors.New("membership level too low")
+
+// membershipRank returns the rank of membership among membershipLevels starting from 1, 0 if it isn't one of them.
+func membershipRank(membership string) int {
+	for i, l := range membershipLevels {
+		if l == membership {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// membershipAtLeast returns true if membership is min or a higher level, or if min is empty.
+func membershipAtLeast(membership, min string) bool {
+	if min == "" {
+		return true
+	}
+
+	rank := membershipRank(membership)
+	return rank > 0 && rank >= membershipRank(min)
+}
+
+// membershipAllows returns true if membership is enough for the ProtectedMapping serving path with method, if any.
+func (r *router) membershipAllows(membership, method, path string) bool {
+	m, ok := r.mappingFor(path, method)
+	return !ok || membershipAtLeast(membership, m.MinMembership)
+}
+
+// membershipGrants returns the grants a DID with membership is allowed among grants, narrowing their methods to the
+// ones whose ProtectedMapping membership requires.
+func (r *router) membershipGrants(membership string, grants []Grant) []Grant {
+	var allowed []Grant
+	for _, g := range grants {
+		var methods []string
+		for _, m := range g.Methods {
+			if r.membershipAllows(membership, m, g.Resource) {
+				methods = append(methods, m)
+			}
+		}
+
+		if len(methods) > 0 {
+			allowed = append(allowed, Grant{Resource: g.Resource, Methods: methods})
+		}
+	}
+
+	return allowed
+}
+
+// membershipResponse is the JSON the LCD returns for a membership query.
+type membershipResponse struct {
+	Result struct {
+		Owner          string `json:"owner"`
+		MembershipType string `json:"membership_type"`
+	} `json:"result"`
+}
+
+// membershipAuthorizer writes the commercio.network membership of did:com DIDs in the membership claim of their
+// tokens.
+type membershipAuthorizer struct {
+	pool *endpointPool
+}
+
+// NewMembershipAuthorizer returns an Authorizer which queries the commercio.network LCD REST servers listed in opts
+// for the membership of the authenticated DID, or of the DID it acts on behalf of, and writes it in the membership
+// claim of the token.
+// It doesn't deny requests on its own: the ProtectedMapping MinMembership of the granted resources does.
+func NewMembershipAuthorizer(opts HTTPOptions) Authorizer {
+	return membershipAuthorizer{newEndpointPool(opts)}
+}
+
+func membershipURL(lcd, did string) string {
+	return fmt.Sprintf(comMembershipPath, lcd, did)
+}
+
+// membership returns the membership of did, empty if it has none.
+func (m membershipAuthorizer) membership(ctx context.Context, did string) (string, error) {
+	if method, _, err := didMethod(did); err != nil || method != MethodCom {
+		return "", nil
+	}
+
+	var resp membershipResponse
+	urlFor := func(lcd string) string { return membershipURL(lcd, did) }
+	if err := m.pool.getJSON(ctx, urlFor, "", did, &resp); err != nil {
+		// the LCD answers 404 to DIDs without a membership
+		if errors.Is(err, ErrDIDNotFound) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("could not query membership, %w", err)
+	}
+
+	return resp.Result.MembershipType, nil
+}
+
+// Authorize implements the Authorizer interface.
+func (m membershipAuthorizer) Authorize(ctx context.Context, req AuthorizationRequest) (Authorization, error) {
+	membership, err := m.membership(ctx, req.principal())
+	if err != nil {
+		return Authorization{}, err
+	}
+
+	a := Authorization{Grants: req.Grants}
+	if membership != "" {
+		a.Claims = map[string]interface{}{membershipClaim: membership}
+	}
+
+	return a, nil
+}
@@ -0,0 +1,183 @@
+package didcomauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_membershipAtLeast(t *testing.T) {
+	tests := []struct {
+		membership string
+		min        string
+		want       bool
+	}{
+		{"", "", true},
+		{MembershipBronze, "", true},
+		{"", MembershipBronze, false},
+		{MembershipBronze, MembershipBronze, true},
+		{MembershipBronze, MembershipSilver, false},
+		{MembershipGold, MembershipSilver, true},
+		{MembershipBlack, MembershipGold, true},
+		{"platinum", MembershipBronze, false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, membershipAtLeast(tt.membership, tt.min), tt.membership+" >= "+tt.min)
+	}
+}
+
+func Test_membershipURL(t *testing.T) {
+	require.Equal(t, "http://lcd/membership/did:com:1", membershipURL("http://lcd", "did:com:1"))
+}
+
+func Test_membershipAuthorizer_Authorize(t *testing.T) {
+	gold := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	none := "did:com:12p24st9asf394jv04e8sxrl9c384jjqwejv0gf"
+	failing := "did:com:1failing"
+
+	m := mux.NewRouter()
+	m.HandleFunc("/membership/{user}", func(w http.ResponseWriter, r *http.Request) {
+		switch mux.Vars(r)["user"] {
+		case gold:
+			_, _ = w.Write([]byte(`{"height":"12","result":{"owner":"` + gold + `","membership_type":"gold"}}`))
+		case failing:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}).Methods(http.MethodGet)
+
+	lcd := httptest.NewServer(m)
+	defer lcd.Close()
+
+	a := NewMembershipAuthorizer(HTTPOptions{Endpoints: []string{lcd.URL}, Retries: -1})
+	grants := []Grant{{Resource: "/protected/profile", Methods: []string{http.MethodGet}}}
+
+	tests := []struct {
+		name    string
+		did     string
+		want    Authorization
+		wantErr bool
+	}{
+		{"membership", gold, Authorization{Grants: grants, Claims: map[string]interface{}{"membership": "gold"}}, false},
+		{"no membership", none, Authorization{Grants: grants}, false},
+		{"other method", "did:key:z6Mk", Authorization{Grants: grants}, false},
+		{"LCD failure", failing, Authorization{}, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authorize(context.Background(), AuthorizationRequest{DID: tt.did, Grants: grants})
+			if tt.wantErr {
+				require.Error(t, err)
+				require.NotEqual(t, ErrAccessDenied, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_router_authorize_membership(t *testing.T) {
+	r := &router{config: Config{
+		ProtectedBasePath: "/protected",
+		ProtectedPaths: []ProtectedMapping{
+			{Methods: []string{http.MethodGet}, Path: "/reports"},
+			{Methods: []string{http.MethodPut}, Path: "/reports", MinMembership: MembershipGold},
+			{Methods: []string{http.MethodGet}, Path: "/vault", MinMembership: MembershipBlack},
+		},
+	}}
+
+	membership := ""
+	r.config.Authorizer = AuthorizerFunc(func(_ context.Context, req AuthorizationRequest) (Authorization, error) {
+		a := Authorization{Grants: req.Grants, Claims: map[string]interface{}{"tier": 1}}
+		if membership != "" {
+			a.Claims[membershipClaim] = membership
+		}
+
+		return a, nil
+	})
+
+	claims := DidComAuthClaims{DID: "did", Resource: "/protected/reports"}
+
+	// methods requiring a higher membership are left out
+	got, err := r.authorize(context.Background(), claims)
+	require.NoError(t, err)
+	require.Empty(t, got.Membership)
+	require.Equal(t, []Grant{{Resource: "/protected/reports", Methods: []string{http.MethodGet}}}, got.Grants)
+
+	membership = MembershipGold
+	got, err = r.authorize(context.Background(), claims)
+	require.NoError(t, err)
+	require.Equal(t, MembershipGold, got.Membership)
+	require.Equal(t, map[string]interface{}{"tier": 1}, got.Extra, "the membership claim is typed")
+	require.Equal(t, []Grant{{Resource: "/protected/reports", Methods: []string{http.MethodGet, http.MethodPut}}}, got.Grants)
+
+	// requests for resources all requiring a higher membership are denied
+	_, err = r.authorize(context.Background(), DidComAuthClaims{DID: "did", Resource: "/protected/vault"})
+	require.Equal(t, ErrAccessDenied, err)
+}
+
+func Test_checkAuth_membership(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{
+		config: Config{
+			JWTSecret:         "secret",
+			ProtectedBasePath: "/protected",
+			ProtectedPaths: []ProtectedMapping{
+				{Methods: []string{http.MethodGet}, Path: "/reports"},
+				{Methods: []string{http.MethodPut}, Path: "/reports", MinMembership: MembershipSilver},
+			},
+		},
+		cp: newCTest(false),
+	}
+
+	var identity Identity
+	protected := checkAuth{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			identity, _ = IdentityFromContext(req.Context())
+			w.WriteHeader(http.StatusOK)
+		}),
+		r: r,
+	}
+
+	tests := []struct {
+		name       string
+		membership string
+		method     string
+		want       int
+	}{
+		{"no membership required", "", http.MethodGet, http.StatusOK},
+		{"no membership", "", http.MethodPut, http.StatusForbidden},
+		{"lower membership", MembershipBronze, http.MethodPut, http.StatusForbidden},
+		{"required membership", MembershipSilver, http.MethodPut, http.StatusOK},
+		{"higher membership", MembershipBlack, http.MethodPut, http.StatusOK},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			claims := DidComAuthClaims{DID: did, Resource: "/protected/reports", Membership: tt.membership}
+			resp, err := r.releaseTokens(context.Background(), claims, time.Time{})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(tt.method, "/protected/reports", nil)
+			req.Header.Set(authHeader, "Bearer "+resp.Token)
+			req.Header.Set(DIDHeader, did)
+			req.Header.Set(ResourceHeader, "/protected/reports")
+
+			rr := httptest.NewRecorder()
+			protected.ServeHTTP(rr, req)
+			require.Equal(t, tt.want, rr.Code)
+			if tt.want == http.StatusOK {
+				require.Equal(t, tt.membership, identity.Membership)
+			}
+		})
+	}
+}
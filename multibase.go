@@ -0,0 +1,51 @@
+package didcomauth
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// multicodecKeys lists the multicodec prefixes of the public keys we support, along with the DDO key type each one
+// corresponds to.
+var multicodecKeys = []struct {
+	prefix  []byte
+	keyType string
+}{
+	{[]byte{0xed, 0x01}, KeyTypeEd25519},            // ed25519-pub
+	{[]byte{0xe7, 0x01}, KeyTypeEcdsaSecp256k12019}, // secp256k1-pub
+	{[]byte{0x80, 0x24}, KeyTypeEcdsaP256},          // p256-pub
+}
+
+// decodeMultibase decodes a base58btc multibase string, the only encoding we support.
+func decodeMultibase(mb string) ([]byte, error) {
+	if len(mb) < 2 || mb[0] != 'z' {
+		return nil, errors.New("only base58btc multibase values are supported")
+	}
+
+	raw := base58.Decode(mb[1:])
+	if len(raw) == 0 {
+		return nil, errors.New("invalid base58btc value")
+	}
+
+	return raw, nil
+}
+
+// parseMulticodecKey parses a multibase, multicodec-prefixed public key.
+func parseMulticodecKey(mb string) (crypto.PublicKey, error) {
+	raw, err := decodeMultibase(mb)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mk := range multicodecKeys {
+		if bytes.HasPrefix(raw, mk.prefix) {
+			return parseRawPublicKey(mk.keyType, raw[len(mk.prefix):])
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported multicodec key type 0x%x", raw[0])
+}
@@ -0,0 +1,341 @@
+package didcomauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// PolicyEffectAllow and PolicyEffectDeny are the effects of policy rules.
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
+
+	// policyAnySubject matches every DID, policyGroupPrefix prefixes group names among rule subjects.
+	policyAnySubject  = "*"
+	policyGroupPrefix = "group:"
+)
+
+// Policy is a declarative access-control policy, mapping DIDs and groups of DIDs to the resources and methods they can
+// access. It's usually loaded from a YAML or JSON file:
+//
+//	groups:
+//	  staff:
+//	    - did:com:1...
+//	rules:
+//	  - id: staff-uploads
+//	    effect: allow
+//	    subjects: ["group:staff"]
+//	    resources: ["/protected/upload/*"]
+//	    methods: ["GET", "PUT"]
+//	  - id: no-deletes
+//	    effect: deny
+//	    subjects: ["*"]
+//	    resources: ["/protected/*"]
+//	    methods: ["DELETE"]
+//
+// Access is denied unless a rule allows it, and deny rules override allow ones.
+type Policy struct {
+	// Groups maps group names to the DIDs they hold.
+	Groups map[string][]string `yaml:"groups" json:"groups"`
+
+	// Rules holds the policy rules, evaluated all together.
+	Rules []PolicyRule `yaml:"rules" json:"rules"`
+}
+
+// PolicyRule allows or denies some subjects access to some resources, with some methods.
+type PolicyRule struct {
+	// ID identifies the rule in policy decisions.
+	ID string `yaml:"id" json:"id"`
+
+	// Effect is either PolicyEffectAllow or PolicyEffectDeny.
+	Effect string `yaml:"effect" json:"effect"`
+
+	// Subjects holds the DIDs the rule applies to, group:<name> for the DIDs of a group, or * for every DID.
+	Subjects []string `yaml:"subjects" json:"subjects"`
+
+	// Resources holds the paths the rule applies to, or resource patterns: prefixes ending with * or mux path
+	// templates.
+	Resources []string `yaml:"resources" json:"resources"`
+
+	// Methods holds the HTTP methods the rule applies to, every method if empty.
+	Methods []string `yaml:"methods,omitempty" json:"methods,omitempty"`
+
+	patterns []resourcePattern
+}
+
+// PolicyDecision is the outcome of the evaluation of a Policy.
+type PolicyDecision struct {
+	Allowed bool
+
+	// Rule is the ID of the rule the decision comes from, empty if no rule applies.
+	Rule string
+
+	// Reason explains the decision.
+	Reason string
+}
+
+// String implements the fmt.Stringer interface.
+func (d PolicyDecision) String() string {
+	if d.Allowed {
+		return "allowed: " + d.Reason
+	}
+
+	return "denied: " + d.Reason
+}
+
+// PolicySource provides the Policy in force, which can change over time.
+type PolicySource interface {
+	// CurrentPolicy returns the Policy in force.
+	CurrentPolicy() *Policy
+}
+
+// ParsePolicy parses a policy from b, in YAML or, since JSON is a subset of YAML, JSON.
+func ParsePolicy(b []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := yaml.UnmarshalStrict(b, p); err != nil {
+		return nil, fmt.Errorf("could not parse policy, %w", err)
+	}
+
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// LoadPolicy loads a policy from the file at path, JSON if its extension is .json and YAML otherwise.
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read policy, %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		p := &Policy{}
+		jdec := json.NewDecoder(bytes.NewReader(b))
+		jdec.DisallowUnknownFields()
+		if err := jdec.Decode(p); err != nil {
+			return nil, fmt.Errorf("could not parse policy, %w", err)
+		}
+
+		if err := p.compile(); err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	}
+
+	return ParsePolicy(b)
+}
+
+// compile validates p and parses the resource patterns of its rules.
+func (p *Policy) compile() error {
+	ids := map[string]bool{}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("#%d", i)
+		}
+
+		if ids[rule.ID] {
+			return fmt.Errorf("duplicate policy rule %s", rule.ID)
+		}
+		ids[rule.ID] = true
+
+		if rule.Effect != PolicyEffectAllow && rule.Effect != PolicyEffectDeny {
+			return fmt.Errorf("policy rule %s has invalid effect %q", rule.ID, rule.Effect)
+		}
+
+		if len(rule.Subjects) == 0 || len(rule.Resources) == 0 {
+			return fmt.Errorf("policy rule %s must have subjects and resources", rule.ID)
+		}
+
+		for _, s := range rule.Subjects {
+			if group := strings.TrimPrefix(s, policyGroupPrefix); group != s {
+				if _, ok := p.Groups[group]; !ok {
+					return fmt.Errorf("policy rule %s refers to unknown group %s", rule.ID, group)
+				}
+			}
+		}
+
+		for _, m := range rule.Methods {
+			if !containsString(validGrantMethods, m) {
+				return fmt.Errorf("policy rule %s has invalid method %q", rule.ID, m)
+			}
+		}
+
+		rule.patterns = make([]resourcePattern, len(rule.Resources))
+		for j, resource := range rule.Resources {
+			rp, err := newPolicyPattern(resource)
+			if err != nil {
+				return fmt.Errorf("policy rule %s: %w", rule.ID, err)
+			}
+
+			rule.patterns[j] = rp
+		}
+	}
+
+	return nil
+}
+
+// newPolicyPattern parses resource, a path or resource pattern of a policy rule.
+func newPolicyPattern(resource string) (resourcePattern, error) {
+	if !isResourcePattern(resource) {
+		if !strings.HasPrefix(resource, "/") {
+			return resourcePattern{}, fmt.Errorf("resource %s must begin with /", resource)
+		}
+
+		return resourcePattern{route: mux.NewRouter().Path(resource)}, nil
+	}
+
+	return newResourcePattern(resource)
+}
+
+// CurrentPolicy implements the PolicySource interface.
+func (p *Policy) CurrentPolicy() *Policy {
+	return p
+}
+
+// memberOf returns true if did is one of subjects, directly or through a group.
+func (p *Policy) memberOf(did string, subjects []string) (string, bool) {
+	for _, s := range subjects {
+		switch {
+		case s == policyAnySubject || s == did:
+			return s, true
+		case strings.HasPrefix(s, policyGroupPrefix):
+			if containsString(p.Groups[strings.TrimPrefix(s, policyGroupPrefix)], did) {
+				return s, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// covers returns true if the resource pattern p matches every path resource matches, resource being a path or a
+// resource pattern.
+func (p resourcePattern) covers(resource string) bool {
+	if p.route == nil {
+		return strings.HasPrefix(strings.TrimSuffix(resource, resourcePatternWildcard), p.prefix)
+	}
+
+	if isResourcePattern(resource) {
+		tpl, err := p.route.GetPathTemplate()
+		return err == nil && tpl == resource
+	}
+
+	return p.match(resource)
+}
+
+// Evaluate tells whether did can access resource, a path or a resource pattern, with method.
+//
+// Access is allowed if an allow rule applies and no deny rule does. Rules apply to a resource if one of their
+// resources covers it, so a pattern is allowed only if a rule allows all of its paths. Deny rules apply to patterns
+// too if they cover them; paths denied within an allowed pattern are denied when accessed instead.
+func (p *Policy) Evaluate(did, method, resource string) PolicyDecision {
+	var allow *PolicyRule
+	var allowSubject, allowResource string
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if len(rule.Methods) > 0 && !containsString(rule.Methods, method) {
+			continue
+		}
+
+		subject, ok := p.memberOf(did, rule.Subjects)
+		if !ok {
+			continue
+		}
+
+		covered := ""
+		for j, rp := range rule.patterns {
+			if rp.covers(resource) {
+				covered = rule.Resources[j]
+				break
+			}
+		}
+
+		if covered == "" {
+			continue
+		}
+
+		if rule.Effect == PolicyEffectDeny {
+			return PolicyDecision{
+				Rule:   rule.ID,
+				Reason: fmt.Sprintf("rule %s denies %s to %s on %s", rule.ID, method, subject, covered),
+			}
+		}
+
+		if allow == nil {
+			allow, allowSubject, allowResource = rule, subject, covered
+		}
+	}
+
+	if allow == nil {
+		return PolicyDecision{Reason: fmt.Sprintf("no rule allows %s %s to %s", method, resource, did)}
+	}
+
+	return PolicyDecision{
+		Allowed: true,
+		Rule:    allow.ID,
+		Reason:  fmt.Sprintf("rule %s allows %s to %s on %s, and no rule denies it", allow.ID, method, allowSubject, allowResource),
+	}
+}
+
+// Authorize narrows the requested grants to the methods p allows on their resources, it implements the Authorizer
+// interface.
+func (p *Policy) Authorize(_ context.Context, req AuthorizationRequest) (Authorization, error) {
+	var grants []Grant
+	for _, g := range req.Grants {
+		var methods []string
+		for _, m := range g.Methods {
+			if p.Evaluate(req.principal(), m, g.Resource).Allowed {
+				methods = append(methods, m)
+			}
+		}
+
+		if len(methods) > 0 {
+			grants = append(grants, Grant{Resource: g.Resource, Methods: methods})
+		}
+	}
+
+	if len(grants) == 0 {
+		return Authorization{}, ErrAccessDenied
+	}
+
+	return Authorization{Grants: grants}, nil
+}
+
+// policy returns the policy in force, nil if Config.Policy isn't set.
+func (r *router) policy() *Policy {
+	if r.config.Policy == nil {
+		return nil
+	}
+
+	return r.config.Policy.CurrentPolicy()
+}
+
+var errPolicyDenied = errors.New("access denied by policy")
+
+// checkPolicy returns an error if the policy in force denies did access to path with method.
+func (r *router) checkPolicy(did, method, path string) error {
+	p := r.policy()
+	if p == nil {
+		return nil
+	}
+
+	if !p.Evaluate(did, method, path).Allowed {
+		return errPolicyDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package didcomauth
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultPolicyReloadInterval is how often a PolicyFile checks whether its file changed.
+const defaultPolicyReloadInterval = 5 * time.Second
+
+// PolicyFile is a PolicySource reloading its Policy from disk whenever the file changes.
+// If the changed file can't be loaded, the last valid policy stays in force.
+type PolicyFile struct {
+	path string
+
+	mu      sync.RWMutex
+	policy  *Policy
+	modTime time.Time
+	size    int64
+
+	done chan struct{}
+	once sync.Once
+}
+
+// NewPolicyFile loads the policy at path, see LoadPolicy, and checks it for changes every interval, 5 seconds if
+// zero. Negative intervals disable automatic reloads.
+func NewPolicyFile(path string, interval time.Duration) (*PolicyFile, error) {
+	f := &PolicyFile{path: path, done: make(chan struct{})}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+
+	if interval == 0 {
+		interval = defaultPolicyReloadInterval
+	}
+
+	if interval > 0 {
+		go f.watch(interval)
+	}
+
+	return f, nil
+}
+
+// CurrentPolicy implements the PolicySource interface.
+func (f *PolicyFile) CurrentPolicy() *Policy {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.policy
+}
+
+// Reload loads the policy from disk, and puts it in force if valid.
+// Invalid files aren't reloaded again until they change.
+func (f *PolicyFile) Reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+
+	p, err := LoadPolicy(f.path)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	if err != nil {
+		return err
+	}
+
+	f.policy = p
+	return nil
+}
+
+// changed returns true if the file changed since it was last loaded.
+func (f *PolicyFile) changed() bool {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return false
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return !info.ModTime().Equal(f.modTime) || info.Size() != f.size
+}
+
+func (f *PolicyFile) watch(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-t.C:
+			if !f.changed() {
+				continue
+			}
+
+			if err := f.Reload(); err != nil {
+				log.Printf("could not reload policy %s, keeping the previous one: %v", f.path, err)
+			}
+		}
+	}
+}
+
+// Close stops checking the file for changes.
+func (f *PolicyFile) Close() {
+	f.once.Do(func() {
+		close(f.done)
+	})
+}
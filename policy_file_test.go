@@ -0,0 +1,53 @@
+package didcomauth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "policy.yaml")
+	modTime := time.Now()
+	write := func(policy string) {
+		// make every change visible on filesystems with coarse modification times
+		require.NoError(t, ioutil.WriteFile(path, []byte(policy), 0600))
+		modTime = modTime.Add(time.Second)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	allowed := func(f *PolicyFile) bool {
+		return f.CurrentPolicy().Evaluate("did", http.MethodGet, "/protected/path").Allowed
+	}
+
+	write("rules:\n  - effect: deny\n    subjects: ['*']\n    resources: ['/protected/*']")
+	_, err = NewPolicyFile(filepath.Join(dir, "missing.yaml"), -1)
+	require.Error(t, err)
+
+	f, err := NewPolicyFile(path, 5*time.Millisecond)
+	require.NoError(t, err)
+	defer f.Close()
+	require.False(t, allowed(f))
+
+	write("rules:\n  - effect: allow\n    subjects: ['*']\n    resources: ['/protected/*']")
+	require.Eventually(t, func() bool { return allowed(f) }, time.Second, 5*time.Millisecond)
+
+	// invalid policies don't replace the one in force
+	write("rules: [")
+	time.Sleep(50 * time.Millisecond)
+	require.True(t, allowed(f))
+	require.Error(t, f.Reload())
+	require.True(t, allowed(f))
+
+	f.Close()
+	f.Close()
+}
@@ -0,0 +1,202 @@
+package didcomauth
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+groups:
+  staff:
+    - did:com:staff
+rules:
+  - id: staff-uploads
+    effect: allow
+    subjects: ["group:staff"]
+    resources: ["/protected/upload/*"]
+    methods: ["GET", "PUT", "DELETE"]
+  - id: profiles
+    effect: allow
+    subjects: ["*"]
+    resources: ["/protected/user/{id}"]
+    methods: ["GET"]
+  - id: guest-upload
+    effect: allow
+    subjects: ["did:com:guest"]
+    resources: ["/protected/upload/public"]
+  - id: no-deletes
+    effect: deny
+    subjects: ["*"]
+    resources: ["/protected/*"]
+    methods: ["DELETE"]
+  - id: secrets
+    effect: deny
+    subjects: ["group:staff"]
+    resources: ["/protected/upload/secret"]
+`
+
+func Test_ParsePolicy(t *testing.T) {
+	_, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{"not yaml", "rules: ["},
+		{"unknown field", "rules:\n  - id: r\n    effect: allow\n    subjects: ['*']\n    resources: ['/']\n    verbs: ['GET']"},
+		{"invalid effect", "rules:\n  - effect: maybe\n    subjects: ['*']\n    resources: ['/']"},
+		{"no subjects", "rules:\n  - effect: allow\n    resources: ['/']"},
+		{"no resources", "rules:\n  - effect: allow\n    subjects: ['*']"},
+		{"unknown group", "rules:\n  - effect: allow\n    subjects: ['group:staff']\n    resources: ['/']"},
+		{"invalid method", "rules:\n  - effect: allow\n    subjects: ['*']\n    resources: ['/']\n    methods: ['get']"},
+		{"relative resource", "rules:\n  - effect: allow\n    subjects: ['*']\n    resources: ['protected']"},
+		{"invalid template", "rules:\n  - effect: allow\n    subjects: ['*']\n    resources: ['/protected/{id']"},
+		{"duplicate rule", "rules:\n  - id: r\n    effect: allow\n    subjects: ['*']\n    resources: ['/']\n  - id: r\n    effect: deny\n    subjects: ['*']\n    resources: ['/']"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.policy))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	jsonPath := filepath.Join(dir, "policy.json")
+	require.NoError(t, ioutil.WriteFile(jsonPath, []byte(`{"rules":[{"id":"all","effect":"allow","subjects":["*"],"resources":["/protected/*"]}]}`), 0600))
+	p, err := LoadPolicy(jsonPath)
+	require.NoError(t, err)
+	require.True(t, p.Evaluate("did", http.MethodGet, "/protected/path").Allowed)
+
+	require.NoError(t, ioutil.WriteFile(jsonPath, []byte(`{"rules":[],"roles":[]}`), 0600))
+	_, err = LoadPolicy(jsonPath)
+	require.Error(t, err)
+
+	yamlPath := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, ioutil.WriteFile(yamlPath, []byte(testPolicy), 0600))
+	_, err = LoadPolicy(yamlPath)
+	require.NoError(t, err)
+
+	_, err = LoadPolicy(filepath.Join(dir, "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	p, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		did      string
+		method   string
+		resource string
+		allowed  bool
+		rule     string
+	}{
+		{"group member, allowed path", "did:com:staff", http.MethodGet, "/protected/upload/12", true, "staff-uploads"},
+		{"group member, allowed pattern", "did:com:staff", http.MethodPut, "/protected/upload/*", true, "staff-uploads"},
+		{"group member, method not allowed", "did:com:staff", http.MethodPost, "/protected/upload/12", false, ""},
+		{"deny overrides allow", "did:com:staff", http.MethodDelete, "/protected/upload/12", false, "no-deletes"},
+		{"deny overrides allow on a path", "did:com:staff", http.MethodGet, "/protected/upload/secret", false, "secrets"},
+		{"partially denied pattern is allowed", "did:com:staff", http.MethodGet, "/protected/upload/*", true, "staff-uploads"},
+		{"not a group member", "did:com:other", http.MethodGet, "/protected/upload/12", false, ""},
+		{"anybody, template", "did:com:other", http.MethodGet, "/protected/user/12", true, "profiles"},
+		{"anybody, same template", "did:com:other", http.MethodGet, "/protected/user/{id}", true, "profiles"},
+		{"anybody, wider pattern", "did:com:other", http.MethodGet, "/protected/user/*", false, ""},
+		{"single DID, any method", "did:com:guest", http.MethodPost, "/protected/upload/public", true, "guest-upload"},
+		{"single DID, other path", "did:com:guest", http.MethodPost, "/protected/upload/12", false, ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d := p.Evaluate(tt.did, tt.method, tt.resource)
+			require.Equal(t, tt.allowed, d.Allowed, d.String())
+			require.Equal(t, tt.rule, d.Rule)
+			require.NotEmpty(t, d.Reason)
+		})
+	}
+}
+
+func TestPolicy_Authorize(t *testing.T) {
+	p, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+
+	a, err := p.Authorize(context.Background(), AuthorizationRequest{
+		DID: "did:com:staff",
+		Grants: []Grant{
+			{Resource: "/protected/upload/*", Methods: []string{http.MethodGet, http.MethodPost, http.MethodDelete}},
+			{Resource: "/protected/admin", Methods: []string{http.MethodGet}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Grant{{Resource: "/protected/upload/*", Methods: []string{http.MethodGet}}}, a.Grants)
+
+	_, err = p.Authorize(context.Background(), AuthorizationRequest{
+		DID:    "did:com:other",
+		Grants: []Grant{{Resource: "/protected/admin", Methods: []string{http.MethodGet}}},
+	})
+	require.Equal(t, ErrAccessDenied, err)
+}
+
+func Test_checkAuth_policy(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	p, err := ParsePolicy([]byte(`
+rules:
+  - id: uploads
+    effect: allow
+    subjects: ["` + did + `"]
+    resources: ["/protected/upload/*"]
+  - id: secret
+    effect: deny
+    subjects: ["*"]
+    resources: ["/protected/upload/secret"]
+`))
+	require.NoError(t, err)
+
+	r := &router{
+		config: Config{JWTSecret: "secret", ResourcePatterns: []string{"/protected/upload/*"}, Policy: p},
+		cp:     newCTest(false),
+	}
+
+	claims, err := r.authorize(context.Background(), DidComAuthClaims{DID: did, Resource: "/protected/upload/*"})
+	require.NoError(t, err)
+	resp, err := r.releaseTokens(context.Background(), claims, time.Time{})
+	require.NoError(t, err)
+
+	_, err = r.authorize(context.Background(), DidComAuthClaims{DID: did, Resource: "/protected/admin"})
+	require.Equal(t, ErrAccessDenied, err)
+
+	protected := checkAuth{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) }),
+		r:    r,
+	}
+
+	// paths denied within the granted pattern are denied when accessed
+	for path, want := range map[string]int{
+		"/protected/upload/12":     http.StatusOK,
+		"/protected/upload/secret": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(authHeader, "Bearer "+resp.Token)
+		req.Header.Set(DIDHeader, did)
+		req.Header.Set(ResourceHeader, "/protected/upload/*")
+
+		rr := httptest.NewRecorder()
+		protected.ServeHTTP(rr, req)
+		require.Equal(t, want, rr.Code, path)
+	}
+}
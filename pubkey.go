@@ -0,0 +1,243 @@
+package didcomauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// DDO public key types we know how to verify signatures with.
+const (
+	KeyTypeRsaSignature       = "RsaSignatureKey2018"
+	KeyTypeRsaVerification    = "RsaVerificationKey2018"
+	KeyTypeSecp256k1          = "Secp256k1VerificationKey2018"
+	KeyTypeEcdsaSecp256k12019 = "EcdsaSecp256k1VerificationKey2019"
+	KeyTypeEd25519            = "Ed25519VerificationKey2018"
+	KeyTypeEcdsaP256          = "EcdsaSecp256r1VerificationKey2019"
+	KeyTypeEd255192020        = "Ed25519VerificationKey2020"
+	KeyTypeMultikey           = "Multikey"
+)
+
+var (
+	oidPublicKeyECDSA  = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidNamedCurveS256  = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	errUnsupportedKey  = errors.New("unsupported public key type")
+	errNoPEMDataInKey  = errors.New("no valid PEM data found")
+	errKeyTypeMismatch = errors.New("public key does not match its declared type")
+)
+
+// subjectPublicKeyInfo is the ASN.1 structure of a PKIX public key, used for the curves x509 does not know about.
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// maxParsedKeys is the number of parsed keys parsePublicKey remembers.
+const maxParsedKeys = 1024
+
+var (
+	parsedKeysMu sync.Mutex
+	parsedKeys   = make(map[string]crypto.PublicKey)
+)
+
+// parsePublicKey parses the key material of a DDO public key of type keyType.
+// PEM-encoded PKIX keys are accepted for every type, secp256k1 and Ed25519 keys can also be published as raw
+// hex-encoded bytes.
+// Successfully parsed keys are remembered, since the same DDOs keep being used to authenticate.
+func parsePublicKey(keyType, material string) (crypto.PublicKey, error) {
+	memoKey := keyType + "\n" + material
+
+	parsedKeysMu.Lock()
+	key, ok := parsedKeys[memoKey]
+	parsedKeysMu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	key, err := parseKeyMaterial(keyType, material)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedKeysMu.Lock()
+	if len(parsedKeys) >= maxParsedKeys {
+		parsedKeys = make(map[string]crypto.PublicKey)
+	}
+	parsedKeys[memoKey] = key
+	parsedKeysMu.Unlock()
+
+	return key, nil
+}
+
+// parseKeyMaterial does the actual parsing for parsePublicKey.
+func parseKeyMaterial(keyType, material string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(material))
+	if block == nil {
+		raw, err := hex.DecodeString(material)
+		if err != nil {
+			return nil, errNoPEMDataInKey
+		}
+
+		return parseRawPublicKey(keyType, raw)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		// x509 doesn't know secp256k1, try that before giving up
+		var sErr error
+		if key, sErr = parseSecp256k1PKIX(block.Bytes); sErr != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkKeyType(keyType, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// parseRawPublicKey parses a secp256k1 or P-256 point or an Ed25519 key, depending on keyType.
+func parseRawPublicKey(keyType string, raw []byte) (crypto.PublicKey, error) {
+	switch keyType {
+	case KeyTypeEcdsaP256:
+		return parseP256Point(raw)
+	case KeyTypeSecp256k1, KeyTypeEcdsaSecp256k12019:
+		key, err := btcec.ParsePubKey(raw, btcec.S256())
+		if err != nil {
+			return nil, fmt.Errorf("invalid secp256k1 public key, %w", err)
+		}
+
+		return key.ToECDSA(), nil
+	case KeyTypeEd25519, KeyTypeEd255192020:
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key length")
+		}
+
+		return ed25519.PublicKey(raw), nil
+	default:
+		return nil, errNoPEMDataInKey
+	}
+}
+
+// parseP256Point parses a compressed or uncompressed P-256 point.
+func parseP256Point(raw []byte) (*ecdsa.PublicKey, error) {
+	curve := elliptic.P256()
+	params := curve.Params()
+
+	var x, y *big.Int
+	switch {
+	case len(raw) == 65 && raw[0] == 4:
+		x, y = elliptic.Unmarshal(curve, raw)
+	case len(raw) == 33 && (raw[0] == 2 || raw[0] == 3):
+		// y² = x³ - 3x + b
+		x = new(big.Int).SetBytes(raw[1:])
+		if x.Cmp(params.P) < 0 {
+			y2 := new(big.Int).Exp(x, big.NewInt(3), params.P)
+			y2.Sub(y2, new(big.Int).Mul(x, big.NewInt(3)))
+			y2.Add(y2, params.B)
+			y2.Mod(y2, params.P)
+
+			if y = new(big.Int).ModSqrt(y2, params.P); y != nil && y.Bit(0) != uint(raw[0]&1) {
+				y.Sub(params.P, y)
+			}
+		}
+	}
+
+	if x == nil || y == nil || !curve.IsOnCurve(x, y) {
+		return nil, errors.New("invalid P-256 public key")
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// parseSecp256k1PKIX parses a DER-encoded PKIX secp256k1 public key.
+func parseSecp256k1PKIX(der []byte) (*ecdsa.PublicKey, error) {
+	var spki subjectPublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &spki); err != nil || len(rest) != 0 {
+		return nil, errors.New("invalid PKIX public key")
+	}
+
+	var curve asn1.ObjectIdentifier
+	if !spki.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, errUnsupportedKey
+	}
+
+	if _, err := asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &curve); err != nil || !curve.Equal(oidNamedCurveS256) {
+		return nil, errUnsupportedKey
+	}
+
+	key, err := btcec.ParsePubKey(spki.PublicKey.RightAlign(), btcec.S256())
+	if err != nil {
+		return nil, fmt.Errorf("invalid secp256k1 public key, %w", err)
+	}
+
+	return key.ToECDSA(), nil
+}
+
+// key kinds, as returned by keyKind.
+const (
+	keyKindRSA       = "rsa"
+	keyKindEd25519   = "ed25519"
+	keyKindP256      = "p256"
+	keyKindSecp256k1 = "secp256k1"
+)
+
+// keyTypeKinds maps each known DDO key type to the kind of key it must hold.
+var keyTypeKinds = map[string]string{
+	KeyTypeRsaSignature:       keyKindRSA,
+	KeyTypeRsaVerification:    keyKindRSA,
+	KeyTypeSecp256k1:          keyKindSecp256k1,
+	KeyTypeEcdsaSecp256k12019: keyKindSecp256k1,
+	KeyTypeEd25519:            keyKindEd25519,
+	KeyTypeEd255192020:        keyKindEd25519,
+	KeyTypeEcdsaP256:          keyKindP256,
+}
+
+// keyKind returns the kind of key, or an error if we can't verify signatures made with it.
+func keyKind(key crypto.PublicKey) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return keyKindRSA, nil
+	case ed25519.PublicKey:
+		return keyKindEd25519, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return keyKindP256, nil
+		case btcec.S256():
+			return keyKindSecp256k1, nil
+		}
+	}
+
+	return "", errUnsupportedKey
+}
+
+// checkKeyType checks that key is supported and, if keyType is a known DDO key type, that it holds the kind of key
+// keyType declares.
+func checkKeyType(keyType string, key crypto.PublicKey) error {
+	kind, err := keyKind(key)
+	if err != nil {
+		return err
+	}
+
+	if expected, known := keyTypeKinds[keyType]; known && expected != kind {
+		return errKeyTypeMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package didcomauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeyPEM returns the PEM-encoded PKIX form of key.
+func testKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	var der []byte
+	if ek, ok := key.(*ecdsa.PublicKey); ok && ek.Curve == btcec.S256() {
+		params, err := asn1.Marshal(oidNamedCurveS256)
+		require.NoError(t, err)
+
+		pk := (*btcec.PublicKey)(ek).SerializeUncompressed()
+		der, err = asn1.Marshal(subjectPublicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidPublicKeyECDSA,
+				Parameters: asn1.RawValue{FullBytes: params},
+			},
+			PublicKey: asn1.BitString{Bytes: pk, BitLength: len(pk) * 8},
+		})
+		require.NoError(t, err)
+	} else {
+		var err error
+		der, err = x509.MarshalPKIXPublicKey(key)
+		require.NoError(t, err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func Test_parsePublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	s256Key, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		keyType  string
+		material string
+		wantKind string
+		wantErr  bool
+	}{
+		{
+			"rsa pem",
+			KeyTypeRsaSignature,
+			testKeyPEM(t, &rsaKey.PublicKey),
+			keyKindRSA,
+			false,
+		},
+		{
+			"ed25519 pem",
+			KeyTypeEd25519,
+			testKeyPEM(t, edKey),
+			keyKindEd25519,
+			false,
+		},
+		{
+			"ed25519 hex",
+			KeyTypeEd25519,
+			hex.EncodeToString(edKey),
+			keyKindEd25519,
+			false,
+		},
+		{
+			"p-256 pem",
+			KeyTypeEcdsaP256,
+			testKeyPEM(t, &p256Key.PublicKey),
+			keyKindP256,
+			false,
+		},
+		{
+			"secp256k1 pem",
+			KeyTypeEcdsaSecp256k12019,
+			testKeyPEM(t, s256Key.PubKey().ToECDSA()),
+			keyKindSecp256k1,
+			false,
+		},
+		{
+			"secp256k1 compressed hex",
+			KeyTypeSecp256k1,
+			hex.EncodeToString(s256Key.PubKey().SerializeCompressed()),
+			keyKindSecp256k1,
+			false,
+		},
+		{
+			"unknown key type, supported key",
+			"SomeFutureKey2030",
+			testKeyPEM(t, edKey),
+			keyKindEd25519,
+			false,
+		},
+		{
+			"key type doesn't match key",
+			KeyTypeRsaSignature,
+			testKeyPEM(t, edKey),
+			"",
+			true,
+		},
+		{
+			"unsupported curve",
+			"",
+			testKeyPEM(t, &p384Key.PublicKey),
+			"",
+			true,
+		},
+		{
+			"hex for a type that must be pem",
+			KeyTypeRsaSignature,
+			hex.EncodeToString(edKey),
+			"",
+			true,
+		},
+		{
+			"garbage",
+			KeyTypeEd25519,
+			"garbage",
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := parsePublicKey(tt.keyType, tt.material)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, key)
+				return
+			}
+
+			require.NoError(t, err)
+			kind, err := keyKind(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantKind, kind)
+		})
+	}
+}
+
+func Test_parseP256Point(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		uncompressed := elliptic.Marshal(elliptic.P256(), key.X, key.Y)
+		compressed := append([]byte{byte(2 + key.Y.Bit(0))}, uncompressed[1:33]...)
+
+		for _, raw := range [][]byte{uncompressed, compressed} {
+			parsed, err := parseP256Point(raw)
+			require.NoError(t, err)
+			require.Equal(t, 0, key.X.Cmp(parsed.X))
+			require.Equal(t, 0, key.Y.Cmp(parsed.Y))
+		}
+	}
+
+	_, err := parseP256Point(append([]byte{2}, make([]byte, 31)...))
+	require.Error(t, err)
+
+	outOfField := bytes.Repeat([]byte{0xff}, 33)
+	outOfField[0] = 2
+	_, err = parseP256Point(outOfField)
+	require.Error(t, err)
+}
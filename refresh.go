@@ -0,0 +1,234 @@
+package didcomauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	defaultRefreshPath = "/refresh"
+	refreshKeyFmt      = "refresh-%s"
+	refreshTokenSize   = 32 // number of random bytes a refresh token is made of
+)
+
+var invalidRefreshTokenError = errors.New("invalid refresh token")
+
+// RefreshRequest is the payload of a refresh request, trading a refresh token for a new access token.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// refreshGrant is what a refresh token grants, as kept in the ChallengeStore.
+// ExpiresAt is the expiry of the first refresh token of the chain, rotated refresh tokens inherit it.
+type refreshGrant struct {
+	DID        string  `json:"did"`
+	OnBehalfOf string  `json:"on_behalf_of,omitempty"`
+	Resource   string  `json:"resource"`
+	Grants     []Grant `json:"grants,omitempty"`
+	IssuedAt   int64   `json:"issued_at"`
+	ExpiresAt  int64   `json:"expires_at"`
+}
+
+// claims returns the claims of the access tokens g is traded for.
+func (g refreshGrant) claims() DidComAuthClaims {
+	return DidComAuthClaims{Resource: g.Resource, DID: g.DID, OnBehalfOf: g.OnBehalfOf, Grants: g.Grants}
+}
+
+// getRefreshKey returns the key a refresh token grant is stored under, refresh tokens themselves are never stored.
+func getRefreshKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf(refreshKeyFmt, hex.EncodeToString(sum[:]))
+}
+
+// tokenLifetime returns the lifetime of the access tokens released for resources, the shortest one among theirs.
+func (r *router) tokenLifetime(resources ...string) time.Duration {
+	def := r.config.TokenLifetime
+	if def <= 0 {
+		def = jwtTokenExpiry
+	}
+
+	var lifetime time.Duration
+	for _, resource := range resources {
+		l := def
+		if m, ok := r.mappingFor(resource, ""); ok && m.TokenLifetime > 0 {
+			l = m.TokenLifetime
+		}
+
+		if lifetime == 0 || l < lifetime {
+			lifetime = l
+		}
+	}
+
+	if lifetime == 0 {
+		lifetime = def
+	}
+
+	return lifetime
+}
+
+// releaseTokens returns an access token carrying claims and, if refresh tokens are enabled, a refresh token
+// expiring at refreshExpiry, or after Config.RefreshTokenLifetime if refreshExpiry is zero.
+// The access token doesn't outlive refreshExpiry either, if set. Its registered claims are set here.
+func (r *router) releaseTokens(ctx context.Context, claims DidComAuthClaims, refreshExpiry time.Time) (ReleaseJWTResponse, error) {
+	lifetime := r.tokenLifetime(claimsResources(claims)...)
+	if !refreshExpiry.IsZero() {
+		if until := time.Until(refreshExpiry).Truncate(time.Second); until > 0 && until < lifetime {
+			lifetime = until
+		}
+	}
+
+	claims.StandardClaims = &jwt.StandardClaims{Issuer: r.config.Issuer, Audience: r.config.Audience}
+	if r.config.CookieOptions.Enabled {
+		csrf, err := newTokenID()
+		if err != nil {
+			return ReleaseJWTResponse{}, err
+		}
+
+		claims.CSRF = csrf
+	}
+
+	token, err := genJWT(&claims, lifetime, r.keyRing().signingKey())
+	if err != nil {
+		return ReleaseJWTResponse{}, err
+	}
+
+	resp := ReleaseJWTResponse{
+		Token:     token,
+		ExpiresIn: int64(lifetime / time.Second),
+		CSRFToken: claims.CSRF,
+	}
+
+	if r.config.RefreshTokenLifetime <= 0 {
+		return resp, nil
+	}
+
+	if refreshExpiry.IsZero() {
+		refreshExpiry = time.Now().Add(r.config.RefreshTokenLifetime)
+	}
+
+	ttl := time.Until(refreshExpiry)
+	if ttl <= 0 {
+		return resp, nil
+	}
+
+	rb := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(rb); err != nil {
+		return ReleaseJWTResponse{}, fmt.Errorf("could not generate refresh token, %w", err)
+	}
+
+	refreshToken := base64.RawURLEncoding.EncodeToString(rb)
+	grant, err := json.Marshal(refreshGrant{
+		DID:        claims.DID,
+		OnBehalfOf: claims.OnBehalfOf,
+		Resource:   claims.Resource,
+		Grants:     claims.Grants,
+		IssuedAt:   time.Now().Unix(),
+		ExpiresAt:  refreshExpiry.Unix(),
+	})
+	if err != nil {
+		return ReleaseJWTResponse{}, err
+	}
+
+	if err := r.cp.Set(ctx, getRefreshKey(refreshToken), grant, ttl); err != nil {
+		return ReleaseJWTResponse{}, fmt.Errorf("could not store refresh token, %w", err)
+	}
+
+	resp.RefreshToken = refreshToken
+	return resp, nil
+}
+
+// refreshPOSTHandler trades a refresh token for a new access token and a new refresh token.
+// Refresh tokens can only be used once, by the DID they were released to and for the same resource.
+func (r *router) refreshPOSTHandler(rw http.ResponseWriter, req *http.Request) {
+	did := req.Header.Get(DIDHeader)
+	resource := req.Header.Get(ResourceHeader)
+
+	var rr RefreshRequest
+	jdec := json.NewDecoder(req.Body)
+	jdec.DisallowUnknownFields()
+	if err := jdec.Decode(&rr); err != nil {
+		writeError(rw, http.StatusBadRequest, fmt.Errorf("could not unmarshal payload, %w", err))
+		return
+	}
+
+	if rr.RefreshToken == "" {
+		writeError(rw, http.StatusBadRequest, errors.New("refresh_token field empty"))
+		return
+	}
+
+	key := getRefreshKey(rr.RefreshToken)
+	b, err := r.cp.Get(req.Context(), key)
+	if err != nil {
+		if err != ErrEntryNotFound {
+			log.Println(err)
+		}
+
+		writeError(rw, http.StatusForbidden, invalidRefreshTokenError)
+		return
+	}
+
+	// refresh tokens are single-use, if somebody else deleted it first they're using it right now
+	if err := r.cp.Delete(req.Context(), key); err != nil {
+		if err != ErrEntryNotFound {
+			log.Println(err)
+		}
+
+		writeError(rw, http.StatusForbidden, invalidRefreshTokenError)
+		return
+	}
+
+	var grant refreshGrant
+	if err := json.Unmarshal(b, &grant); err != nil {
+		log.Println(err)
+		writeError(rw, http.StatusForbidden, invalidRefreshTokenError)
+		return
+	}
+
+	if grant.DID != did || grant.Resource != resource {
+		writeError(rw, http.StatusForbidden, invalidRefreshTokenError)
+		return
+	}
+
+	revoked, err := r.claimsRevoked(req.Context(), grant.claims(), grant.IssuedAt)
+	if err != nil {
+		log.Println(err)
+		writeError(rw, http.StatusInternalServerError, errors.New("could not check token revocation"))
+		return
+	}
+
+	if revoked {
+		writeError(rw, http.StatusForbidden, invalidRefreshTokenError)
+		return
+	}
+
+	claims, err := r.authorize(req.Context(), grant.claims())
+	if err != nil {
+		writeAuthorizationError(rw, err)
+		return
+	}
+
+	resp, err := r.releaseTokens(req.Context(), claims, time.Unix(grant.ExpiresAt, 0))
+	if err != nil {
+		log.Println(err)
+		writeError(rw, http.StatusInternalServerError, errors.New("could not generate jwt token"))
+		return
+	}
+
+	r.setTokenCookies(rw, resp)
+
+	jenc := json.NewEncoder(rw)
+	if err := jenc.Encode(resp); err != nil {
+		writeError(rw, http.StatusInternalServerError, fmt.Errorf("could not marshal token, %w", err))
+	}
+}
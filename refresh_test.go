@@ -0,0 +1,122 @@
+package didcomauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+)
+
+func refreshRequest(t *testing.T, r *router, did, resource, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewReader([]byte(body)))
+	req.Header.Set(DIDHeader, did)
+	req.Header.Set(ResourceHeader, resource)
+
+	rr := httptest.NewRecorder()
+	r.refreshPOSTHandler(rr, req)
+	return rr
+}
+
+func Test_router_releaseTokens(t *testing.T) {
+	r := &router{
+		config: Config{JWTSecret: "secret", TokenLifetime: time.Minute},
+		cp:     newCTest(false),
+	}
+
+	resp, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: "did", Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, int64(60), resp.ExpiresIn)
+	require.Empty(t, resp.RefreshToken, "refresh tokens are disabled by default")
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(resp.Token, claims, r.tokenKey)
+	require.NoError(t, err)
+	require.InDelta(t, time.Now().Add(time.Minute).Unix(), claims["exp"], 2)
+
+	r.config.RefreshTokenLifetime = time.Hour
+	resp, err = r.releaseTokens(context.Background(), DidComAuthClaims{DID: "did", Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.RefreshToken)
+
+	// refresh tokens expiring in the past aren't released
+	resp, err = r.releaseTokens(context.Background(), DidComAuthClaims{DID: "did", Resource: "/path"}, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	require.Empty(t, resp.RefreshToken)
+
+	// store failures are reported
+	r.cp = newCTest(true)
+	_, err = r.releaseTokens(context.Background(), DidComAuthClaims{DID: "did", Resource: "/path"}, time.Time{})
+	require.Error(t, err)
+}
+
+func Test_router_refreshPOSTHandler(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{
+		config: Config{JWTSecret: "secret", RefreshTokenLifetime: time.Hour},
+		cp:     newCTest(false),
+	}
+
+	first, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: did, Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+
+	body := func(token string) string {
+		b, err := json.Marshal(RefreshRequest{RefreshToken: token})
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	// a refresh token is traded for a new token and a new refresh token
+	rr := refreshRequest(t, r, did, "/path", body(first.RefreshToken))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var second ReleaseJWTResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &second))
+	require.NotEmpty(t, second.Token)
+	require.NotEmpty(t, second.RefreshToken)
+	require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	// rotated refresh tokens keep the expiry of the first one
+	b, err := r.cp.Get(context.Background(), getRefreshKey(second.RefreshToken))
+	require.NoError(t, err)
+	var grant refreshGrant
+	require.NoError(t, json.Unmarshal(b, &grant))
+	require.InDelta(t, time.Now().Add(time.Hour).Unix(), grant.ExpiresAt, 2)
+
+	// refresh tokens are single-use
+	rr = refreshRequest(t, r, did, "/path", body(first.RefreshToken))
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Contains(t, rr.Body.String(), "invalid refresh token")
+
+	tests := []struct {
+		name           string
+		did            string
+		resource       string
+		body           string
+		expectedStatus int
+		expectedData   string
+	}{
+		{"not json", did, "/path", "refresh", http.StatusBadRequest, "could not unmarshal payload"},
+		{"unknown field", did, "/path", `{"token":"abc"}`, http.StatusBadRequest, "could not unmarshal payload"},
+		{"empty refresh token", did, "/path", body(""), http.StatusBadRequest, "refresh_token field empty"},
+		{"unknown refresh token", did, "/path", body("unknown"), http.StatusForbidden, "invalid refresh token"},
+		{"another DID", "did:com:12p24st9asf394jv04e8sxrl9c384jjqwejv0gf", "/path", body(second.RefreshToken), http.StatusForbidden, "invalid refresh token"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rr := refreshRequest(t, r, tt.did, tt.resource, tt.body)
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			require.Contains(t, rr.Body.String(), tt.expectedData)
+		})
+	}
+
+	// a refresh token presented by the wrong DID is burnt
+	rr = refreshRequest(t, r, did, "/path", body(second.RefreshToken))
+	require.Equal(t, http.StatusForbidden, rr.Code)
+}
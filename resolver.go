@@ -0,0 +1,79 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrDIDNotFound is returned by DIDResolver implementations when the DID doesn't have a DID document.
+var ErrDIDNotFound = errors.New("DID document not found")
+
+// statusError is returned by getJSON when the server answers with an unexpected status code.
+type statusError struct {
+	code int
+}
+
+func (se statusError) Error() string {
+	return fmt.Sprintf("DID resolver responded with status %d", se.code)
+}
+
+// DIDResolver resolves a DID to its DID document.
+type DIDResolver interface {
+	// Resolve returns the DID document of did, or an error wrapping ErrDIDNotFound if there is none.
+	Resolve(ctx context.Context, did string) (DIDDocument, error)
+}
+
+// DIDResolverFunc is an adapter to use ordinary functions as DIDResolver.
+type DIDResolverFunc func(ctx context.Context, did string) (DIDDocument, error)
+
+// Resolve implements the DIDResolver interface.
+func (f DIDResolverFunc) Resolve(ctx context.Context, did string) (DIDDocument, error) {
+	return f(ctx, did)
+}
+
+// getJSON GETs url and decodes its JSON body in v.
+// A 404 response yields an error wrapping ErrDIDNotFound.
+func getJSON(ctx context.Context, client *http.Client, url, accept string, did string, v interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("could not resolve DDO, %w", err)
+	}
+
+	req = req.WithContext(ctx)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	data, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not resolve DDO, %w", err)
+	}
+
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, data.Body)
+		_ = data.Body.Close()
+	}()
+
+	if data.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("ddo for %s not found, %w", did, ErrDIDNotFound)
+	}
+
+	if data.StatusCode != http.StatusOK {
+		return statusError{data.StatusCode}
+	}
+
+	if err := json.NewDecoder(data.Body).Decode(v); err != nil {
+		return fmt.Errorf("could not unmarshal DDO, %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	gatewayDDOResolutionPath = "%s/commercionetwork/did/identities/%s"
+)
+
+// gatewayResolveResponse is the JSON the commercio.network gRPC-gateway returns for an identity query.
+type gatewayResolveResponse struct {
+	Identity struct {
+		DidDocument *DIDDocument `json:"didDocument"`
+	} `json:"identity"`
+}
+
+// gatewayResolver resolves commercio.network DIDs through the Cosmos gRPC-gateway DID endpoint.
+type gatewayResolver struct {
+	pool *endpointPool
+}
+
+// NewGatewayResolver returns a DIDResolver which queries the commercio.network gRPC-gateway REST servers listed in opts.
+func NewGatewayResolver(opts HTTPOptions) DIDResolver {
+	return gatewayResolver{newEndpointPool(opts)}
+}
+
+func gatewayURL(endpoint, did string) string {
+	return fmt.Sprintf(gatewayDDOResolutionPath, endpoint, did)
+}
+
+// Resolve implements the DIDResolver interface.
+func (g gatewayResolver) Resolve(ctx context.Context, did string) (DIDDocument, error) {
+	var grr gatewayResolveResponse
+	urlFor := func(endpoint string) string { return gatewayURL(endpoint, did) }
+	if err := g.pool.getJSON(ctx, urlFor, "", did, &grr); err != nil {
+		return DIDDocument{}, err
+	}
+
+	if grr.Identity.DidDocument == nil {
+		return DIDDocument{}, errors.New("ddo resolution okay but document is empty")
+	}
+
+	return *grr.Identity.DidDocument, nil
+}
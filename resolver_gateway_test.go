@@ -0,0 +1,72 @@
+package didcomauth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gatewayURL(t *testing.T) {
+	require.Equal(t,
+		"http://test/commercionetwork/did/identities/did",
+		gatewayURL("http://test", "did"),
+	)
+}
+
+func Test_gatewayResolver_Resolve(t *testing.T) {
+	endpoint := "http://gateway"
+	did := "did:com:1"
+
+	tests := []struct {
+		name      string
+		responder httpmock.Responder
+		wantErr   bool
+	}{
+		{
+			"not found",
+			httpmock.NewStringResponder(http.StatusNotFound, `{"code": 5}`),
+			true,
+		},
+		{
+			"no document",
+			httpmock.NewStringResponder(http.StatusOK, `{"identity": {}}`),
+			true,
+		},
+		{
+			"okay",
+			httpmock.NewStringResponder(http.StatusOK, `{
+				"identity": {
+					"didDocument": {
+						"id": "did:com:1",
+						"verificationMethod": [{"id": "did:com:1#keys-1", "type": "Ed25519VerificationKey2018"}],
+						"authentication": ["did:com:1#keys-1"]
+					},
+					"metadata": {"created": "2021-01-01T00:00:00Z"}
+				}
+			}`),
+			false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, gatewayURL(endpoint, did), tt.responder)
+
+			doc, err := NewGatewayResolver(HTTPOptions{Endpoints: []string{endpoint}}).Resolve(context.Background(), did)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, did, doc.ID)
+			require.Len(t, doc.AuthenticationMethods(), 1)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package didcomauth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// keyResolver resolves did:key DIDs, whose DID document is derived from the DID itself.
+type keyResolver struct{}
+
+// NewKeyResolver returns a DIDResolver for did:key DIDs.
+// Ed25519, secp256k1 and P-256 keys encoded as base58btc multibase values are supported.
+func NewKeyResolver() DIDResolver {
+	return keyResolver{}
+}
+
+// Resolve implements the DIDResolver interface.
+func (keyResolver) Resolve(_ context.Context, did string) (DIDDocument, error) {
+	method, id, err := didMethod(did)
+	if err != nil {
+		return DIDDocument{}, err
+	}
+
+	if method != MethodKey || strings.ContainsAny(id, ":#/?") {
+		return DIDDocument{}, fmt.Errorf("%s is not a did:key DID", did)
+	}
+
+	if _, err := parseMulticodecKey(id); err != nil {
+		return DIDDocument{}, fmt.Errorf("invalid did:key DID, %w", err)
+	}
+
+	vm := VerificationMethod{
+		ID:                 did + "#" + id,
+		Type:               KeyTypeMultikey,
+		Controller:         did,
+		PublicKeyMultibase: id,
+	}
+
+	return DIDDocument{
+		ID:                 did,
+		VerificationMethod: []VerificationMethod{vm},
+		Authentication:     []VerificationRelationship{{Reference: vm.ID}},
+	}, nil
+}
@@ -0,0 +1,69 @@
+package didcomauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+)
+
+// did:key test vectors from the did:key method specification.
+const (
+	testDIDKeyEd25519   = "did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+	testDIDKeySecp256k1 = "did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme"
+	testDIDKeyP256      = "did:key:zDnaerDaTF5BXEavCrfRZEk316dpbLsfPDZ3WJ5hRTPFU2169"
+)
+
+func Test_keyResolver_Resolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		did      string
+		wantKind string
+		wantErr  bool
+	}{
+		{"ed25519", testDIDKeyEd25519, keyKindEd25519, false},
+		{"secp256k1", testDIDKeySecp256k1, keyKindSecp256k1, false},
+		{"p-256", testDIDKeyP256, keyKindP256, false},
+		{"not a did:key", "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc", "", true},
+		{"not base58btc", "did:key:f0123", "", true},
+		{"unknown multicodec", "did:key:z" + base58.Encode([]byte{0x12, 0x20, 0x01}), "", true},
+		{"truncated key", testDIDKeyEd25519[:len(testDIDKeyEd25519)-4], "", true},
+		{"did url", testDIDKeyEd25519 + "#key", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewKeyResolver().Resolve(context.Background(), tt.did)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.did, doc.ID)
+
+			key, err := signingKey(AuthenticationKeySelector{}, doc, "")
+			require.NoError(t, err)
+			kind, err := keyKind(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantKind, kind)
+		})
+	}
+}
+
+func Test_keyResolver_Resolve_signature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	did := "did:key:z" + base58.Encode(append([]byte{0xed, 0x01}, pub...))
+	doc, err := NewKeyResolver().Resolve(context.Background(), did)
+	require.NoError(t, err)
+
+	payload := []byte("challenge")
+	key, err := signingKey(AuthenticationKeySelector{}, doc, doc.VerificationMethod[0].ID)
+	require.NoError(t, err)
+	require.NoError(t, verifySignature(AlgorithmEdDSA, key, payload, ed25519.Sign(priv, payload)))
+}
@@ -0,0 +1,87 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_getJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		responder    httpmock.Responder
+		wantErr      bool
+		wantNotFound bool
+	}{
+		{
+			"http call goes error",
+			httpmock.NewErrorResponder(errors.New("error!")),
+			true,
+			false,
+		},
+		{
+			"not found",
+			httpmock.NewStringResponder(http.StatusNotFound, "not found"),
+			true,
+			true,
+		},
+		{
+			"non-200",
+			httpmock.NewStringResponder(http.StatusBadGateway, "bad gateway"),
+			true,
+			false,
+		},
+		{
+			"wrong json",
+			httpmock.NewStringResponder(http.StatusOK, "this is not json"),
+			true,
+			false,
+		},
+		{
+			"okay",
+			func(req *http.Request) (*http.Response, error) {
+				if req.Header.Get("Accept") != "application/json" {
+					return httpmock.NewStringResponse(http.StatusBadRequest, ""), nil
+				}
+
+				return httpmock.NewStringResponse(http.StatusOK, `{"id": "did"}`), nil
+			},
+			false,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, "http://resolver/did", tt.responder)
+
+			var doc DIDDocument
+			err := getJSON(context.Background(), nil, "http://resolver/did", "application/json", "did", &doc)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Equal(t, tt.wantNotFound, errors.Is(err, ErrDIDNotFound))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "did", doc.ID)
+		})
+	}
+}
+
+func TestDIDResolverFunc_Resolve(t *testing.T) {
+	f := DIDResolverFunc(func(ctx context.Context, did string) (DIDDocument, error) {
+		return DIDDocument{ID: did}, nil
+	})
+
+	doc, err := f.Resolve(context.Background(), "did")
+	require.NoError(t, err)
+	require.Equal(t, "did", doc.ID)
+}
@@ -0,0 +1,70 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	universalResolutionPath   = "%s/1.0/identifiers/%s"
+	universalResolutionAccept = `application/ld+json;profile="https://w3id.org/did-resolution"`
+)
+
+// universalResolveResponse is a DID resolution result as returned by a DIF Universal Resolver.
+type universalResolveResponse struct {
+	DidDocument           *DIDDocument `json:"didDocument"`
+	DidResolutionMetadata struct {
+		Error string `json:"error"`
+	} `json:"didResolutionMetadata"`
+}
+
+// universalResolver resolves DIDs of any method through a DIF Universal Resolver.
+type universalResolver struct {
+	pool *endpointPool
+}
+
+// NewUniversalResolver returns a DIDResolver which queries the DIF Universal Resolvers listed in opts.
+func NewUniversalResolver(opts HTTPOptions) DIDResolver {
+	return universalResolver{newEndpointPool(opts)}
+}
+
+func universalURL(endpoint, did string) string {
+	return fmt.Sprintf(universalResolutionPath, endpoint, did)
+}
+
+// Resolve implements the DIDResolver interface.
+func (u universalResolver) Resolve(ctx context.Context, did string) (DIDDocument, error) {
+	var raw json.RawMessage
+	urlFor := func(endpoint string) string { return universalURL(endpoint, did) }
+	if err := u.pool.getJSON(ctx, urlFor, universalResolutionAccept, did, &raw); err != nil {
+		return DIDDocument{}, err
+	}
+
+	// resolvers answer either with a resolution result or with the bare document, depending on their version
+	var urr universalResolveResponse
+	if err := json.Unmarshal(raw, &urr); err != nil {
+		return DIDDocument{}, fmt.Errorf("could not unmarshal DDO, %w", err)
+	}
+
+	switch {
+	case urr.DidResolutionMetadata.Error == "notFound":
+		return DIDDocument{}, fmt.Errorf("ddo for %s not found, %w", did, ErrDIDNotFound)
+	case urr.DidResolutionMetadata.Error != "":
+		return DIDDocument{}, fmt.Errorf("could not resolve DDO, %s", urr.DidResolutionMetadata.Error)
+	case urr.DidDocument != nil:
+		return *urr.DidDocument, nil
+	}
+
+	var doc DIDDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return DIDDocument{}, fmt.Errorf("could not unmarshal DDO, %w", err)
+	}
+
+	if doc.ID == "" {
+		return DIDDocument{}, errors.New("ddo resolution okay but document is empty")
+	}
+
+	return doc, nil
+}
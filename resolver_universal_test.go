@@ -0,0 +1,89 @@
+package didcomauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_universalURL(t *testing.T) {
+	require.Equal(t,
+		"http://test/1.0/identifiers/did:key:z6Mk",
+		universalURL("http://test", "did:key:z6Mk"),
+	)
+}
+
+func Test_universalResolver_Resolve(t *testing.T) {
+	endpoint := "http://uniresolver"
+	did := "did:example:1"
+
+	tests := []struct {
+		name         string
+		responder    httpmock.Responder
+		wantErr      bool
+		wantNotFound bool
+	}{
+		{
+			"http not found",
+			httpmock.NewStringResponder(http.StatusNotFound, ""),
+			true,
+			true,
+		},
+		{
+			"resolution metadata not found",
+			httpmock.NewStringResponder(http.StatusOK, `{"didResolutionMetadata": {"error": "notFound"}}`),
+			true,
+			true,
+		},
+		{
+			"resolution metadata error",
+			httpmock.NewStringResponder(http.StatusOK, `{"didResolutionMetadata": {"error": "invalidDid"}}`),
+			true,
+			false,
+		},
+		{
+			"empty body",
+			httpmock.NewStringResponder(http.StatusOK, `{}`),
+			true,
+			false,
+		},
+		{
+			"resolution result",
+			httpmock.NewStringResponder(http.StatusOK, `{
+				"didDocument": {"id": "did:example:1"},
+				"didResolutionMetadata": {"contentType": "application/did+ld+json"}
+			}`),
+			false,
+			false,
+		},
+		{
+			"bare document",
+			httpmock.NewStringResponder(http.StatusOK, `{"@context": "https://www.w3.org/ns/did/v1", "id": "did:example:1"}`),
+			false,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, universalURL(endpoint, did), tt.responder)
+
+			doc, err := NewUniversalResolver(HTTPOptions{Endpoints: []string{endpoint}}).Resolve(context.Background(), did)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Equal(t, tt.wantNotFound, errors.Is(err, ErrDIDNotFound))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, did, doc.ID)
+		})
+	}
+}
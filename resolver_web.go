@@ -0,0 +1,270 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	maxWebDocumentSize = 1 << 20 // largest DID document fetched from did:web hosts
+	maxWebRedirects    = 10
+)
+
+// privateNetworks holds the address ranges which aren't reachable from the internet, besides the loopback,
+// link-local, multicast and unspecified ones.
+var privateNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets
+}
+
+// publicIP returns true if ip is reachable from the internet.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dialPublicOnly is a net.Dialer Control function refusing connections to addresses which aren't public. It sees
+// the resolved address, hence it can't be tricked by host names pointing to private ones.
+func dialPublicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("did:web address %s not allowed", host)
+	}
+
+	return nil
+}
+
+// WebResolverOptions configures the DIDResolver returned by NewWebResolver.
+type WebResolverOptions struct {
+	// Client fetches the DID documents. If nil, a client with a 5 seconds timeout which only connects to public
+	// addresses, without going through proxies, is used: a custom Client is responsible for its own policy.
+	Client *http.Client
+
+	// AllowedHosts lists the hosts, such as example.com or example.com:3000, DID documents can be fetched from. Any
+	// host is allowed if empty.
+	AllowedHosts []string
+
+	// AllowPrivateAddresses lets the default client connect to loopback, private and link-local addresses.
+	AllowPrivateAddresses bool
+}
+
+// webResolver resolves did:web DIDs by fetching their DID document over HTTPS.
+type webResolver struct {
+	client       *http.Client
+	allowedHosts []string
+}
+
+// NewWebResolver returns a DIDResolver for did:web DIDs, which fetches DID documents as opts tells.
+// DID documents larger than 1 MiB are rejected, as well as redirects to hosts not allowed.
+func NewWebResolver(opts WebResolverOptions) DIDResolver {
+	w := webResolver{allowedHosts: opts.AllowedHosts}
+
+	var client http.Client
+	if opts.Client != nil {
+		client = *opts.Client
+	} else {
+		client.Timeout = defaultHTTPTimeout
+		if !opts.AllowPrivateAddresses {
+			client.Transport = &http.Transport{
+				DialContext:         (&net.Dialer{Timeout: defaultHTTPTimeout, Control: dialPublicOnly}).DialContext,
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: defaultHTTPTimeout,
+			}
+		}
+	}
+
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxWebRedirects {
+			return errors.New("too many redirects")
+		}
+
+		if err := w.checkURL(req.URL); err != nil {
+			return err
+		}
+
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+
+		return nil
+	}
+
+	w.client = &client
+	return w
+}
+
+// checkURL returns an error if DID documents can't be fetched from u.
+func (w webResolver) checkURL(u *url.URL) error {
+	if u.Scheme != "https" {
+		return errors.New("did:web documents must be fetched over HTTPS")
+	}
+
+	allowed := len(w.allowedHosts) == 0 ||
+		containsString(w.allowedHosts, u.Host) || containsString(w.allowedHosts, u.Hostname())
+	if allowed {
+		return nil
+	}
+
+	return fmt.Errorf("did:web host %s not allowed", u.Host)
+}
+
+// webDocumentURL returns the URL of the DID document of the did:web DID whose method-specific identifier is id, as
+// per the did:web specification: the first segment is the percent-encoded host, the next ones an optional path.
+func webDocumentURL(id string) (string, error) {
+	if strings.ContainsAny(id, "/?#") {
+		return "", errors.New("invalid did:web identifier")
+	}
+
+	segments := strings.Split(id, ":")
+	for i, s := range segments {
+		unescaped, err := url.PathUnescape(s)
+		if err != nil || unescaped == "" || strings.Contains(unescaped, "/") {
+			return "", errors.New("invalid did:web identifier")
+		}
+
+		segments[i] = unescaped
+	}
+
+	host := segments[0]
+	if u, err := url.Parse("https://" + host); err != nil || u.Host != host || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid did:web host %s", host)
+	}
+
+	if len(segments) == 1 {
+		return "https://" + host + "/.well-known/did.json", nil
+	}
+
+	path := make([]string, 0, len(segments)-1)
+	for _, s := range segments[1:] {
+		path = append(path, url.PathEscape(s))
+	}
+
+	return "https://" + host + "/" + strings.Join(path, "/") + "/did.json", nil
+}
+
+// Resolve implements the DIDResolver interface.
+func (w webResolver) Resolve(ctx context.Context, did string) (DIDDocument, error) {
+	method, id, err := didMethod(did)
+	if err != nil {
+		return DIDDocument{}, err
+	}
+
+	if method != MethodWeb {
+		return DIDDocument{}, fmt.Errorf("%s is not a did:web DID", did)
+	}
+
+	docURL, err := webDocumentURL(id)
+	if err != nil {
+		return DIDDocument{}, err
+	}
+
+	doc, err := w.fetch(ctx, docURL, did)
+	if err != nil {
+		return DIDDocument{}, err
+	}
+
+	if doc.ID != did {
+		return DIDDocument{}, fmt.Errorf("DID document at %s is about another DID", docURL)
+	}
+
+	return doc, nil
+}
+
+// fetch GETs the DID document of did at docURL. Its errors never carry the content the host answered with.
+func (w webResolver) fetch(ctx context.Context, docURL, did string) (DIDDocument, error) {
+	u, err := url.Parse(docURL)
+	if err != nil {
+		return DIDDocument{}, fmt.Errorf("could not resolve DDO, %w", err)
+	}
+
+	if err := w.checkURL(u); err != nil {
+		return DIDDocument{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, docURL, nil)
+	if err != nil {
+		return DIDDocument{}, fmt.Errorf("could not resolve DDO, %w", err)
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/did+json, application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return DIDDocument{}, fmt.Errorf("could not resolve DDO, %w", err)
+	}
+
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxWebDocumentSize))
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return DIDDocument{}, fmt.Errorf("ddo for %s not found, %w", did, ErrDIDNotFound)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return DIDDocument{}, statusError{resp.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxWebDocumentSize+1))
+	if err != nil {
+		return DIDDocument{}, fmt.Errorf("could not resolve DDO, %w", err)
+	}
+
+	if len(body) > maxWebDocumentSize {
+		return DIDDocument{}, fmt.Errorf("DID document at %s too large", docURL)
+	}
+
+	var doc DIDDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return DIDDocument{}, fmt.Errorf("DID document at %s invalid", docURL)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,162 @@
+package didcomauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_webDocumentURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{"domain only", "example.com", "https://example.com/.well-known/did.json", false},
+		{"with path", "example.com:user:alice", "https://example.com/user/alice/did.json", false},
+		{"with port", "example.com%3A3000:user", "https://example.com:3000/user/did.json", false},
+		{"empty path segment", "example.com::alice", "", true},
+		{"slash in identifier", "example.com/alice", "", true},
+		{"encoded slash in path", "example.com:a%2Fb", "", true},
+		{"bad host", "exa mple.com", "", true},
+		{"port only", "%3A3000", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := webDocumentURL(tt.id)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_webResolver_Resolve(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	base := "did:web:" + strings.Replace(u.Host, ":", "%3A", 1)
+	docs := map[string]DIDDocument{
+		"/.well-known/did.json": {
+			ID: base,
+			VerificationMethod: []VerificationMethod{
+				{ID: base + "#key-1", Type: KeyTypeMultikey, PublicKeyMultibase: strings.TrimPrefix(testDIDKeyEd25519, "did:key:")},
+			},
+			Authentication: []VerificationRelationship{{Reference: "#key-1"}},
+		},
+		"/user/alice/did.json":   {ID: base + ":user:alice"},
+		"/user/mallory/did.json": {ID: base + ":user:alice"},
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/huge/did.json":
+			_, _ = w.Write([]byte(`{"id":"` + strings.Repeat("a", maxWebDocumentSize) + `"}`))
+			return
+		case "/user/garbage/did.json":
+			_, _ = w.Write([]byte(`<script>alert("owned")</script>`))
+			return
+		case "/user/redirect/did.json":
+			http.Redirect(w, r, "https://example.com/did.json", http.StatusFound)
+			return
+		}
+
+		doc, ok := docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(doc)
+	})
+
+	tests := []struct {
+		name       string
+		did        string
+		wantErr    bool
+		wantErrStr string
+	}{
+		{"domain document", base, false, ""},
+		{"path document", base + ":user:alice", false, ""},
+		{"document about another DID", base + ":user:mallory", true, "is about another DID"},
+		{"no document", base + ":user:bob", true, "not found"},
+		{"document too large", base + ":user:huge", true, "too large"},
+		{"invalid document", base + ":user:garbage", true, "invalid"},
+		{"not a did:web", testDIDKeyEd25519, true, "not a did:web"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewWebResolver(WebResolverOptions{Client: srv.Client()}).Resolve(context.Background(), tt.did)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErrStr)
+				require.NotContains(t, err.Error(), "script", "remote content isn't echoed")
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.did, doc.ID)
+		})
+	}
+
+	// the default client doesn't connect to the loopback test server
+	_, err = NewWebResolver(WebResolverOptions{}).Resolve(context.Background(), base)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not allowed")
+
+	// nor trusts its certificate when it's allowed to
+	_, err = NewWebResolver(WebResolverOptions{AllowPrivateAddresses: true}).Resolve(context.Background(), base)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "not allowed")
+	require.False(t, errors.Is(err, ErrDIDNotFound))
+
+	// hosts must be allowed, even when redirected to
+	allowed := NewWebResolver(WebResolverOptions{Client: srv.Client(), AllowedHosts: []string{"127.0.0.1"}})
+	_, err = allowed.Resolve(context.Background(), base)
+	require.NoError(t, err)
+
+	_, err = allowed.Resolve(context.Background(), base+":user:redirect")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "example.com not allowed")
+
+	_, err = NewWebResolver(WebResolverOptions{Client: srv.Client(), AllowedHosts: []string{"example.com"}}).
+		Resolve(context.Background(), base)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not allowed")
+
+	doc, err := NewWebResolver(WebResolverOptions{Client: srv.Client()}).Resolve(context.Background(), base)
+	require.NoError(t, err)
+	_, err = signingKey(AuthenticationKeySelector{}, doc, "")
+	require.NoError(t, err)
+}
+
+func Test_publicIP(t *testing.T) {
+	for _, ip := range []string{"8.8.8.8", "2001:4860:4860::8888"} {
+		require.True(t, publicIP(net.ParseIP(ip)), ip)
+	}
+
+	for _, ip := range []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "100.64.0.1", "169.254.169.254", "0.0.0.0",
+		"224.0.0.1", "::1", "fd00::1", "fe80::1", "::", "::ffff:127.0.0.1",
+	} {
+		require.False(t, publicIP(net.ParseIP(ip)), ip)
+	}
+}
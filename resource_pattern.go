@@ -0,0 +1,94 @@
+package didcomauth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// resourcePatternWildcard ends the resource patterns matching every path they prefix.
+const resourcePatternWildcard = "*"
+
+var errResourcePatternNotAllowed = errors.New("resource pattern not allowed")
+
+// resourcePattern is a resource pattern tokens can be requested for, either a prefix or a mux path template.
+type resourcePattern struct {
+	prefix string
+	route  *mux.Route
+}
+
+// newResourcePattern parses pattern, a prefix ending with resourcePatternWildcard or a mux path template.
+func newResourcePattern(pattern string) (resourcePattern, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return resourcePattern{}, fmt.Errorf("resource pattern %s must begin with /", pattern)
+	}
+
+	if strings.HasSuffix(pattern, resourcePatternWildcard) {
+		return resourcePattern{prefix: strings.TrimSuffix(pattern, resourcePatternWildcard)}, nil
+	}
+
+	route := mux.NewRouter().Path(pattern)
+	if err := route.GetError(); err != nil {
+		return resourcePattern{}, fmt.Errorf("invalid resource pattern %s, %w", pattern, err)
+	}
+
+	return resourcePattern{route: route}, nil
+}
+
+// match returns true if path matches p.
+func (p resourcePattern) match(path string) bool {
+	if p.route == nil {
+		return strings.HasPrefix(path, p.prefix)
+	}
+
+	var match mux.RouteMatch
+	return p.route.Match(&http.Request{URL: &url.URL{Path: path}}, &match)
+}
+
+// isResourcePattern returns true if resource looks like a resource pattern rather than a path.
+func isResourcePattern(resource string) bool {
+	return strings.HasSuffix(resource, resourcePatternWildcard) || strings.Contains(resource, "{")
+}
+
+// resourcePatterns returns the parsed Config.ResourcePatterns, by pattern.
+// Invalid patterns are rejected by Config.Validate, and ignored here.
+func (r *router) resourcePatterns() map[string]resourcePattern {
+	r.patternsOnce.Do(func() {
+		r.patterns = make(map[string]resourcePattern, len(r.config.ResourcePatterns))
+		for _, pattern := range r.config.ResourcePatterns {
+			if p, err := newResourcePattern(pattern); err == nil {
+				r.patterns[pattern] = p
+			}
+		}
+	})
+
+	return r.patterns
+}
+
+// checkResource returns an error if resource is a pattern that isn't in Config.ResourcePatterns.
+func (r *router) checkResource(resource string) error {
+	if !isResourcePattern(resource) {
+		return nil
+	}
+
+	if _, ok := r.resourcePatterns()[resource]; !ok {
+		return errResourcePatternNotAllowed
+	}
+
+	return nil
+}
+
+// resourceMatches returns true if a token released for resource gives access to path: resource is either path
+// itself, or an allowed resource pattern path matches.
+func (r *router) resourceMatches(resource, path string) bool {
+	if resource == path {
+		return true
+	}
+
+	p, ok := r.resourcePatterns()[resource]
+	return ok && p.match(path)
+}
@@ -0,0 +1,99 @@
+package didcomauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resourcePattern_match(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"prefix", "/protected/upload/*", "/protected/upload/12", true},
+		{"prefix, nested path", "/protected/upload/*", "/protected/upload/12/thumbnail", true},
+		{"prefix, other folder", "/protected/upload/*", "/protected/profile", false},
+		{"template", "/protected/upload/{id}", "/protected/upload/12", true},
+		{"template, nested path", "/protected/upload/{id}", "/protected/upload/12/thumbnail", false},
+		{"template with regexp", "/protected/upload/{id:[0-9]+}", "/protected/upload/12", true},
+		{"template with regexp, no match", "/protected/upload/{id:[0-9]+}", "/protected/upload/abc", false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newResourcePattern(tt.pattern)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, p.match(tt.path))
+		})
+	}
+
+	_, err := newResourcePattern("protected/*")
+	require.Error(t, err)
+	_, err = newResourcePattern("/protected/{id")
+	require.Error(t, err)
+}
+
+func Test_router_resourceMatches(t *testing.T) {
+	r := &router{config: Config{ResourcePatterns: []string{"/protected/upload/*", "/protected/user/{id}"}}}
+
+	require.True(t, r.resourceMatches("/protected/profile", "/protected/profile"))
+	require.False(t, r.resourceMatches("/protected/profile", "/protected/profile/picture"))
+	require.True(t, r.resourceMatches("/protected/upload/*", "/protected/upload/12"))
+	require.True(t, r.resourceMatches("/protected/user/{id}", "/protected/user/12"))
+
+	// patterns the owner doesn't allow never match
+	require.False(t, r.resourceMatches("/protected/*", "/protected/upload/12"))
+
+	require.NoError(t, r.checkResource("/protected/profile"))
+	require.NoError(t, r.checkResource("/protected/upload/*"))
+	require.Equal(t, errResourcePatternNotAllowed, r.checkResource("/protected/*"))
+	require.Equal(t, errResourcePatternNotAllowed, r.checkResource("/protected/{folder}/{id}"))
+}
+
+func Test_router_challengePOSTHandler_resourcePattern(t *testing.T) {
+	r := &router{config: Config{JWTSecret: "secret", ResourcePatterns: []string{"/protected/upload/*"}}, cp: newCTest(false)}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/challenge", nil)
+	req.Header.Set(DIDHeader, "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc")
+	req.Header.Set(ResourceHeader, "/protected/*")
+
+	rr := httptest.NewRecorder()
+	r.challengePOSTHandler(rr, req)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Contains(t, rr.Body.String(), "resource pattern not allowed")
+}
+
+func Test_checkAuth_resourcePattern(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{config: Config{JWTSecret: "secret", ResourcePatterns: []string{"/protected/upload/*"}}, cp: newCTest(false)}
+
+	resp, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: did, Resource: "/protected/upload/*"}, time.Time{})
+	require.NoError(t, err)
+
+	protected := checkAuth{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) }),
+		r:    r,
+	}
+
+	for path, want := range map[string]int{
+		"/protected/upload/12": http.StatusOK,
+		"/protected/upload/13": http.StatusOK,
+		"/protected/profile":   http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(authHeader, "Bearer "+resp.Token)
+		req.Header.Set(DIDHeader, did)
+		req.Header.Set(ResourceHeader, "/protected/upload/*")
+
+		rr := httptest.NewRecorder()
+		protected.ServeHTTP(rr, req)
+		require.Equal(t, want, rr.Code, path)
+	}
+}
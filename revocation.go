@@ -0,0 +1,219 @@
+package didcomauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLogoutPath  = "/logout"
+	revokedTokenKeyFmt = "revoked-%s"
+	revokedDIDKeyFmt   = "revoked-did-%s"
+	tokenIDSize        = 16 // number of random bytes a token ID is made of
+
+	// revocationMargin is added to the time revocations are kept for, to account for clock skew between servers.
+	revocationMargin = time.Minute
+)
+
+var errNotConfigured = errors.New("didcomauth is not configured")
+
+// newTokenID returns a random token ID, used as jti claim.
+func newTokenID() (string, error) {
+	b := make([]byte, tokenIDSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate token ID, %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func getRevokedTokenKey(jti string) string {
+	return fmt.Sprintf(revokedTokenKeyFmt, jti)
+}
+
+func getRevokedDIDKey(did string) string {
+	return fmt.Sprintf(revokedDIDKeyFmt, did)
+}
+
+// maxTokenLifetime returns the longest time a token or refresh token released now can be used for.
+func (r *router) maxTokenLifetime() time.Duration {
+	lifetime := r.config.TokenLifetime
+	if lifetime <= 0 {
+		lifetime = jwtTokenExpiry
+	}
+
+	for _, m := range r.config.ProtectedPaths {
+		if m.TokenLifetime > lifetime {
+			lifetime = m.TokenLifetime
+		}
+	}
+
+	if r.config.RefreshTokenLifetime > lifetime {
+		lifetime = r.config.RefreshTokenLifetime
+	}
+
+	return lifetime
+}
+
+// revocationRetention returns the time revocations are kept for beyond the expiry of what they revoke: as long as
+// tokens are accepted after exp, hence Config.ClockSkewLeeway, and at least revocationMargin.
+func (r *router) revocationRetention() time.Duration {
+	if r.config.ClockSkewLeeway > revocationMargin {
+		return r.config.ClockSkewLeeway
+	}
+
+	return revocationMargin
+}
+
+// revokeToken revokes the token identified by jti, which expires at expiresAt.
+func (r *router) revokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt) + r.revocationRetention()
+	if ttl <= 0 {
+		return nil
+	}
+
+	return r.cp.Set(ctx, getRevokedTokenKey(jti), []byte{1}, ttl)
+}
+
+// revokeDID revokes every token and refresh token released to did until now.
+func (r *router) revokeDID(ctx context.Context, did string) error {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	return r.cp.Set(ctx, getRevokedDIDKey(did), []byte(now), r.maxTokenLifetime()+r.revocationRetention())
+}
+
+// revoked returns true if the token identified by jti, released to did at issuedAt, has been revoked.
+// Tokens released in the same second every token of their DID has been revoked are revoked too.
+func (r *router) revoked(ctx context.Context, did, jti string, issuedAt int64) (bool, error) {
+	if jti != "" {
+		if _, err := r.cp.Get(ctx, getRevokedTokenKey(jti)); err != ErrEntryNotFound {
+			return err == nil, err
+		}
+	}
+
+	b, err := r.cp.Get(ctx, getRevokedDIDKey(did))
+	switch {
+	case err == ErrEntryNotFound:
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	revokedAt, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid DID revocation, %w", err)
+	}
+
+	return issuedAt <= revokedAt, nil
+}
+
+// claimsRevoked returns true if the token carrying claims, issued at issuedAt, has been revoked: either by itself or
+// along with the tokens of its DID or, for delegated tokens, of the DID it acts on behalf of.
+func (r *router) claimsRevoked(ctx context.Context, claims DidComAuthClaims, issuedAt int64) (bool, error) {
+	var jti string
+	if claims.StandardClaims != nil {
+		jti = claims.Id
+	}
+
+	revoked, err := r.revoked(ctx, claims.DID, jti, issuedAt)
+	if err != nil || revoked || claims.OnBehalfOf == "" {
+		return revoked, err
+	}
+
+	return r.revoked(ctx, claims.OnBehalfOf, "", issuedAt)
+}
+
+// RevokeDID revokes every token and refresh token released to did, or on its behalf, until now, through the instance
+// set up by Configure.
+func RevokeDID(ctx context.Context, did string) error {
+	if instance == nil {
+		return errNotConfigured
+	}
+
+	return instance.revokeDID(ctx, did)
+}
+
+// LogoutRequest is the optional payload of a logout request, naming the refresh token to revoke along with the token.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// logoutPOSTHandler revokes the token the request is authenticated with and, if given, its refresh token.
+func (r *router) logoutPOSTHandler(rw http.ResponseWriter, req *http.Request) {
+	bearer, fromCookie := r.requestToken(req)
+	if bearer == "" {
+		writeError(rw, http.StatusForbidden, notAuthorized)
+		return
+	}
+
+	claims, err := r.parseToken(bearer)
+	if err != nil {
+		writeError(rw, http.StatusForbidden, invalidTokenError)
+		return
+	}
+
+	if fromCookie {
+		if err := checkCSRF(req, claims); err != nil {
+			writeError(rw, http.StatusForbidden, err)
+			return
+		}
+	}
+
+	var lr LogoutRequest
+	if req.ContentLength != 0 {
+		jdec := json.NewDecoder(req.Body)
+		jdec.DisallowUnknownFields()
+		if err := jdec.Decode(&lr); err != nil {
+			writeError(rw, http.StatusBadRequest, fmt.Errorf("could not unmarshal payload, %w", err))
+			return
+		}
+	}
+
+	if claims.Id == "" {
+		writeError(rw, http.StatusBadRequest, errors.New("token can't be revoked, it has no ID"))
+		return
+	}
+
+	if err := r.revokeToken(req.Context(), claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		log.Println(err)
+		writeError(rw, http.StatusInternalServerError, errors.New("could not revoke token"))
+		return
+	}
+
+	if lr.RefreshToken != "" {
+		r.revokeRefreshToken(req.Context(), claims.DID, lr.RefreshToken)
+	}
+
+	r.clearTokenCookies(rw)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// revokeRefreshToken deletes refreshToken if it was released to did.
+func (r *router) revokeRefreshToken(ctx context.Context, did, refreshToken string) {
+	key := getRefreshKey(refreshToken)
+
+	b, err := r.cp.Get(ctx, key)
+	if err != nil {
+		if err != ErrEntryNotFound {
+			log.Println(err)
+		}
+
+		return
+	}
+
+	var grant refreshGrant
+	if err := json.Unmarshal(b, &grant); err != nil || grant.DID != did {
+		return
+	}
+
+	if err := r.cp.Delete(ctx, key); err != nil && err != ErrEntryNotFound {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,242 @@
+package didcomauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+)
+
+func logoutRequest(t *testing.T, r *router, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", bytes.NewReader([]byte(body)))
+	if token != "" {
+		req.Header.Set(authHeader, "Bearer "+token)
+	}
+
+	rr := httptest.NewRecorder()
+	r.logoutPOSTHandler(rr, req)
+	return rr
+}
+
+func Test_genJWT_tokenID(t *testing.T) {
+	key := JWTKey{Algorithm: AlgorithmHS512, Key: []byte("secret")}
+
+	first, err := genJWT(&DidComAuthClaims{Resource: "/path", DID: "did"}, time.Minute, key)
+	require.NoError(t, err)
+	second, err := genJWT(&DidComAuthClaims{Resource: "/path", DID: "did"}, time.Minute, key)
+	require.NoError(t, err)
+
+	ids := map[string]bool{}
+	for _, token := range []string{first, second} {
+		claims := jwt.MapClaims{}
+		_, _, err := new(jwt.Parser).ParseUnverified(token, claims)
+		require.NoError(t, err)
+		require.NotEmpty(t, claims["jti"])
+		require.InDelta(t, time.Now().Unix(), claims["iat"], 2)
+		ids[claims["jti"].(string)] = true
+	}
+
+	require.Len(t, ids, 2, "every token has its own ID")
+}
+
+func Test_router_revoked(t *testing.T) {
+	ctx := context.Background()
+	r := &router{config: Config{JWTSecret: "secret"}, cp: newCTest(false)}
+	now := time.Now().Unix()
+
+	revoked, err := r.revoked(ctx, "did", "jti", now)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, r.revokeToken(ctx, "jti", time.Now().Add(time.Minute)))
+	revoked, err = r.revoked(ctx, "did", "jti", now)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = r.revoked(ctx, "did", "other", now)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// tokens issued before the DID has been revoked are revoked, the ones issued later aren't
+	require.NoError(t, r.revokeDID(ctx, "did"))
+	revoked, err = r.revoked(ctx, "did", "other", now-10)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = r.revoked(ctx, "did", "other", now+10)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revoked, err = r.revoked(ctx, "another did", "other", now-10)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// store failures are reported
+	r.cp = newCTest(true)
+	_, err = r.revoked(ctx, "did", "jti", now)
+	require.Error(t, err)
+}
+
+func Test_router_maxTokenLifetime(t *testing.T) {
+	r := &router{config: Config{ProtectedPaths: []ProtectedMapping{{Path: "/long", TokenLifetime: time.Hour}}}}
+	require.Equal(t, time.Hour, r.maxTokenLifetime())
+
+	r.config.RefreshTokenLifetime = 24 * time.Hour
+	require.Equal(t, 24*time.Hour, r.maxTokenLifetime())
+
+	r = &router{}
+	require.Equal(t, jwtTokenExpiry, r.maxTokenLifetime())
+}
+
+func Test_router_revocationRetention(t *testing.T) {
+	r := &router{}
+	require.Equal(t, revocationMargin, r.revocationRetention())
+
+	r.config.ClockSkewLeeway = 5 * time.Minute
+	require.Equal(t, 5*time.Minute, r.revocationRetention())
+}
+
+func TestRevokeDID(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{config: Config{JWTSecret: "secret"}, cp: newCTest(false)}
+
+	old := instance
+	defer func() { instance = old }()
+
+	instance = nil
+	require.Equal(t, errNotConfigured, RevokeDID(context.Background(), did))
+
+	instance = r
+	require.NoError(t, RevokeDID(context.Background(), did))
+
+	b, err := r.cp.Get(context.Background(), getRevokedDIDKey(did))
+	require.NoError(t, err)
+	revokedAt, err := strconv.ParseInt(string(b), 10, 64)
+	require.NoError(t, err)
+	require.InDelta(t, time.Now().Unix(), revokedAt, 2)
+}
+
+func Test_router_logoutPOSTHandler(t *testing.T) {
+	setCosmosConfig()
+
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{
+		config: Config{JWTSecret: "secret", RefreshTokenLifetime: time.Hour},
+		cp:     newCTest(false),
+	}
+
+	resp, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: did, Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+
+	protected := checkAuth{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) }),
+		r:    r,
+	}
+	access := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/path", nil)
+		req.Header.Set(authHeader, "Bearer "+resp.Token)
+		req.Header.Set(DIDHeader, did)
+		req.Header.Set(ResourceHeader, "/path")
+
+		rr := httptest.NewRecorder()
+		protected.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusOK, access())
+
+	tests := []struct {
+		name           string
+		token          string
+		body           string
+		expectedStatus int
+		expectedData   string
+	}{
+		{"no token", "", "", http.StatusForbidden, "not authorized"},
+		{"invalid token", "token", "", http.StatusForbidden, "invalid token"},
+		{"unknown field", resp.Token, `{"token":"abc"}`, http.StatusBadRequest, "could not unmarshal payload"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rr := logoutRequest(t, r, tt.token, tt.body)
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			require.Contains(t, rr.Body.String(), tt.expectedData)
+		})
+	}
+
+	body, err := json.Marshal(LogoutRequest{RefreshToken: resp.RefreshToken})
+	require.NoError(t, err)
+
+	rr := logoutRequest(t, r, resp.Token, string(body))
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	// both the token and its refresh token are revoked
+	require.Equal(t, http.StatusForbidden, access())
+	_, err = r.cp.Get(context.Background(), getRefreshKey(resp.RefreshToken))
+	require.Equal(t, ErrEntryNotFound, err)
+
+	// tokens without ID can't be revoked
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{
+		"did":      did,
+		"resource": "/path",
+		"exp":      time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	rr = logoutRequest(t, r, legacy, "")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// tokens still accepted within the leeway stay revoked as long as they're accepted
+	r.config.ClockSkewLeeway = 5 * time.Minute
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{
+		"jti":      "expired",
+		"did":      did,
+		"resource": "/path",
+		"exp":      time.Now().Add(-2 * time.Minute).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	claims, err := r.parseToken(expired)
+	require.NoError(t, err)
+
+	rr = logoutRequest(t, r, expired, "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	revoked, err := r.claimsRevoked(context.Background(), *claims, claims.IssuedAt)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// store failures are reported
+	resp, err = r.releaseTokens(context.Background(), DidComAuthClaims{DID: did, Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+	r.cp = newCTest(true)
+	rr = logoutRequest(t, r, resp.Token, "")
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func Test_router_refreshPOSTHandler_revokedDID(t *testing.T) {
+	did := "did:com:15jv74vsdk23pvvf2a8arex339505mgjytz98xc"
+	r := &router{
+		config: Config{JWTSecret: "secret", RefreshTokenLifetime: time.Hour},
+		cp:     newCTest(false),
+	}
+
+	resp, err := r.releaseTokens(context.Background(), DidComAuthClaims{DID: did, Resource: "/path"}, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, r.revokeDID(context.Background(), did))
+
+	body, err := json.Marshal(RefreshRequest{RefreshToken: resp.RefreshToken})
+	require.NoError(t, err)
+
+	rr := refreshRequest(t, r, did, "/path", string(body))
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Contains(t, rr.Body.String(), "invalid refresh token")
+}
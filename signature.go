@@ -0,0 +1,135 @@
+package didcomauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Signature algorithms a challenge response can be signed with, named after their JWA identifiers.
+const (
+	AlgorithmRS256  = "RS256"  // RSASSA-PKCS1-v1_5 using SHA-256
+	AlgorithmPS256  = "PS256"  // RSASSA-PSS using SHA-256
+	AlgorithmEdDSA  = "EdDSA"  // Ed25519
+	AlgorithmES256  = "ES256"  // ECDSA using P-256 and SHA-256
+	AlgorithmES256K = "ES256K" // ECDSA using secp256k1 and SHA-256
+)
+
+var errSignatureInvalid = errors.New("signature verification failed")
+
+// algorithmKinds maps each supported algorithm to the kind of key it works with.
+var algorithmKinds = map[string]string{
+	AlgorithmRS256:  keyKindRSA,
+	AlgorithmPS256:  keyKindRSA,
+	AlgorithmEdDSA:  keyKindEd25519,
+	AlgorithmES256:  keyKindP256,
+	AlgorithmES256K: keyKindSecp256k1,
+}
+
+// defaultAlgorithms maps each kind of key to the algorithm assumed when a response doesn't specify one.
+var defaultAlgorithms = map[string]string{
+	keyKindRSA:       AlgorithmRS256,
+	keyKindEd25519:   AlgorithmEdDSA,
+	keyKindP256:      AlgorithmES256,
+	keyKindSecp256k1: AlgorithmES256K,
+}
+
+// supportedAlgorithm returns true if alg is a signature algorithm we can verify.
+func supportedAlgorithm(alg string) bool {
+	_, ok := algorithmKinds[alg]
+	return ok
+}
+
+// signingAlgorithm returns alg if it can be used with key, or the default algorithm for key if alg is empty.
+func signingAlgorithm(alg string, key crypto.PublicKey) (string, error) {
+	kind, err := keyKind(key)
+	if err != nil {
+		return "", err
+	}
+
+	if alg == "" {
+		return defaultAlgorithms[kind], nil
+	}
+
+	algKind, ok := algorithmKinds[alg]
+	if !ok {
+		return "", fmt.Errorf("signature algorithm %s not supported", alg)
+	}
+
+	if algKind != kind {
+		return "", fmt.Errorf("signature algorithm %s can't be used with a %s key", alg, kind)
+	}
+
+	return alg, nil
+}
+
+// ecdsaSignature is the ASN.1 structure of a DER-encoded ECDSA signature.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// verifySignature checks that sig is a valid signature of payload made with alg and the private counterpart of key:
+//   - RS256 and PS256 verify PKCS#1 v1.5 and PSS signatures over the SHA-256 hash of payload
+//   - EdDSA verifies Ed25519 signatures over payload itself
+//   - ES256 and ES256K verify ECDSA signatures over the SHA-256 hash of payload, either DER-encoded or as the 64 bytes
+//     concatenation of r and s
+func verifySignature(alg string, key crypto.PublicKey, payload, sig []byte) error {
+	if _, err := signingAlgorithm(alg, key); err != nil {
+		return err
+	}
+
+	phash := sha256.Sum256(payload)
+
+	switch alg {
+	case AlgorithmRS256:
+		if err := rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, phash[:], sig); err != nil {
+			return errSignatureInvalid
+		}
+	case AlgorithmPS256:
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}
+		if err := rsa.VerifyPSS(key.(*rsa.PublicKey), crypto.SHA256, phash[:], sig, opts); err != nil {
+			return errSignatureInvalid
+		}
+	case AlgorithmEdDSA:
+		if !ed25519.Verify(key.(ed25519.PublicKey), payload, sig) {
+			return errSignatureInvalid
+		}
+	case AlgorithmES256, AlgorithmES256K:
+		r, s, err := parseECDSASignature(sig)
+		if err != nil {
+			return err
+		}
+
+		if !ecdsa.Verify(key.(*ecdsa.PublicKey), phash[:], r, s) {
+			return errSignatureInvalid
+		}
+	default:
+		return fmt.Errorf("signature algorithm %s not supported", alg)
+	}
+
+	return nil
+}
+
+// parseECDSASignature returns r and s from either a DER-encoded or a 64 bytes r || s signature.
+func parseECDSASignature(sig []byte) (*big.Int, *big.Int, error) {
+	if len(sig) == 64 {
+		return new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]), nil
+	}
+
+	var es ecdsaSignature
+	if rest, err := asn1.Unmarshal(sig, &es); err != nil || len(rest) != 0 || es.R == nil || es.S == nil {
+		return nil, nil, errSignatureInvalid
+	}
+
+	if es.R.Sign() <= 0 || es.S.Sign() <= 0 {
+		return nil, nil, errSignatureInvalid
+	}
+
+	return es.R, es.S, nil
+}
@@ -0,0 +1,120 @@
+package didcomauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_verifySignature(t *testing.T) {
+	payload := []byte("payload")
+	phash := sha256.Sum256(payload)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, phash[:])
+	require.NoError(t, err)
+	pssSig, err := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, phash[:], nil)
+	require.NoError(t, err)
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edSig := ed25519.Sign(edPriv, payload)
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p256R, p256S, err := ecdsa.Sign(rand.Reader, p256Key, phash[:])
+	require.NoError(t, err)
+	p256Sig, err := asn1.Marshal(ecdsaSignature{p256R, p256S})
+	require.NoError(t, err)
+
+	s256Key, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	s256Sig, err := s256Key.Sign(phash[:])
+	require.NoError(t, err)
+	s256Raw := append(padTo32(s256Sig.R.Bytes()), padTo32(s256Sig.S.Bytes())...)
+
+	tests := []struct {
+		name    string
+		alg     string
+		key     crypto.PublicKey
+		sig     []byte
+		wantErr bool
+	}{
+		{"rsa pkcs1v15", AlgorithmRS256, &rsaKey.PublicKey, rsaSig, false},
+		{"rsa pkcs1v15, bad signature", AlgorithmRS256, &rsaKey.PublicKey, edSig, true},
+		{"rsa pkcs1v15, pss signature", AlgorithmRS256, &rsaKey.PublicKey, pssSig, true},
+		{"rsa pss", AlgorithmPS256, &rsaKey.PublicKey, pssSig, false},
+		{"rsa pss, pkcs1v15 signature", AlgorithmPS256, &rsaKey.PublicKey, rsaSig, true},
+		{"ed25519", AlgorithmEdDSA, edPub, edSig, false},
+		{"ed25519, bad signature", AlgorithmEdDSA, edPub, rsaSig, true},
+		{"p-256 der", AlgorithmES256, &p256Key.PublicKey, p256Sig, false},
+		{"p-256, bad signature", AlgorithmES256, &p256Key.PublicKey, s256Sig.Serialize(), true},
+		{"secp256k1 der", AlgorithmES256K, s256Key.PubKey().ToECDSA(), s256Sig.Serialize(), false},
+		{"secp256k1 r || s", AlgorithmES256K, s256Key.PubKey().ToECDSA(), s256Raw, false},
+		{"secp256k1, bad signature", AlgorithmES256K, s256Key.PubKey().ToECDSA(), []byte("garbage"), true},
+		{"algorithm doesn't match key", AlgorithmES256, s256Key.PubKey().ToECDSA(), s256Sig.Serialize(), true},
+		{"no algorithm", "", edPub, edSig, true},
+		{"unsupported key", AlgorithmEdDSA, "key", edSig, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(tt.alg, tt.key, payload, tt.sig)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_signingAlgorithm(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		alg     string
+		key     crypto.PublicKey
+		want    string
+		wantErr bool
+	}{
+		{"rsa default", "", &rsaKey.PublicKey, AlgorithmRS256, false},
+		{"rsa pss", AlgorithmPS256, &rsaKey.PublicKey, AlgorithmPS256, false},
+		{"ed25519 default", "", edPub, AlgorithmEdDSA, false},
+		{"algorithm for another key kind", AlgorithmPS256, edPub, "", true},
+		{"unknown algorithm", "HS256", &rsaKey.PublicKey, "", true},
+		{"unsupported key", "", "key", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			alg, err := signingAlgorithm(tt.alg, tt.key)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, alg)
+		})
+	}
+}
+
+func padTo32(b []byte) []byte {
+	return append(make([]byte, 32-len(b)), b...)
+}